@@ -1,6 +1,10 @@
 package api
 
-import "github.com/prebid/go-gdpr/consentconstants"
+import (
+	"time"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+)
 
 // VendorList is an interface used to fetch information about an IAB Global Vendor list.
 // For the latest version, see: https://vendorlist.consensu.org/vendorlist.json
@@ -40,3 +44,137 @@ type Vendor interface {
 	// SpecialFeature returns true if this vendor claims a need for the given special feature
 	SpecialFeature(featureID consentconstants.SpecialFeature) (hasSpecialFeature bool)
 }
+
+// VendorMetadata describes a vendor beyond the purposes which it declares, such as its name and the way it
+// stores information on devices. The vendors of the vendorlist2 package implement it. Use a type assertion
+// to get it from a Vendor:
+//
+//	if metadata, ok := vendor.(api.VendorMetadata); ok {
+//		log.Printf("The vendor's name is %s", metadata.Name())
+//	}
+type VendorMetadata interface {
+	Vendor
+
+	// Name returns the name of the vendor.
+	Name() string
+	// PolicyURL returns the URL of the vendor's privacy policy.
+	// Vendor lists of specification version 3 and later leave it empty.
+	PolicyURL() string
+	// DeletedDate returns the time when the vendor was deleted from the vendor list,
+	// or the zero time if it wasn't.
+	DeletedDate() time.Time
+	// UsesCookies returns true if the vendor stores information on devices with cookies.
+	UsesCookies() bool
+	// CookieMaxAgeSeconds returns the longest duration, in seconds, of the cookies which the vendor sets.
+	// Zero and negative values mean that the cookies only last for the session. This is only meaningful
+	// if UsesCookies returns true.
+	CookieMaxAgeSeconds() int64
+	// CookieRefresh returns true if the vendor refreshes the duration of its cookies.
+	CookieRefresh() bool
+	// UsesNonCookieAccess returns true if the vendor stores or accesses information on devices without cookies,
+	// such as with local storage.
+	UsesNonCookieAccess() bool
+	// DeviceStorageDisclosureURL returns the URL of the vendor's device storage disclosure, which lists
+	// the information that it stores on devices.
+	DeviceStorageDisclosureURL() string
+}
+
+// VendorDataDeclaration describes how a vendor handles personal data, as declared in vendor lists of
+// specification version 3 and later. The vendors of the vendorlist2 package implement it.
+type VendorDataDeclaration interface {
+	Vendor
+
+	// StdRetention returns the number of days for which the vendor keeps personal data,
+	// for the purposes without a specific retention period. The second return value is false if the
+	// vendor didn't declare it.
+	StdRetention() (days int, ok bool)
+	// PurposeRetention returns the number of days for which the vendor keeps personal data for the purpose.
+	// This falls back to StdRetention if the vendor didn't declare a specific retention period for the purpose.
+	PurposeRetention(purposeID consentconstants.Purpose) (days int, ok bool)
+	// SpecialPurposeRetention returns the number of days for which the vendor keeps personal data for the
+	// special purpose. This falls back to StdRetention like PurposeRetention.
+	SpecialPurposeRetention(purposeID consentconstants.Purpose) (days int, ok bool)
+	// URLs returns the vendor's privacy policy and legitimate interest claim URLs, in every language
+	// which the vendor provides.
+	URLs() []VendorURL
+	// DataDeclaration returns the IDs of the data categories which the vendor collects.
+	// See VendorListDataCategories for their definitions.
+	DataDeclaration() []uint8
+}
+
+// VendorURL holds the URLs of a vendor in one language.
+type VendorURL struct {
+	// LangID is the language of the URLs, as a lowercase two-letter ISO 639-1 code.
+	LangID string `json:"langId"`
+	// Privacy is the URL of the vendor's privacy policy.
+	Privacy string `json:"privacy"`
+	// LegIntClaim is the URL of the vendor's legitimate interest claim. It is empty if the vendor
+	// doesn't rely on legitimate interest.
+	LegIntClaim string `json:"legIntClaim"`
+}
+
+// VendorListDataCategories gives the definitions of the data categories, which vendor lists of specification
+// version 3 and later include. The vendor lists of the vendorlist2 package implement it.
+type VendorListDataCategories interface {
+	VendorList
+
+	// DataCategory returns the data category with the given ID. The second return value is false if
+	// the vendor list doesn't define it.
+	DataCategory(id uint8) (DataCategory, bool)
+	// DataCategories returns every data category, sorted by ID.
+	DataCategories() []DataCategory
+}
+
+// DataCategory is a category of personal data which vendors declare collecting.
+type DataCategory struct {
+	ID          uint8  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// VendorListDefinitions gives the names and descriptions of the purposes, special purposes, features and
+// special features of a vendor list, in its language. The vendor lists of the vendorlist2 package implement it.
+//
+// The lookups return false if the vendor list doesn't define the ID. The lists are sorted by ID.
+type VendorListDefinitions interface {
+	VendorList
+
+	Purpose(id consentconstants.Purpose) (Definition, bool)
+	Purposes() []Definition
+	SpecialPurpose(id consentconstants.Purpose) (Definition, bool)
+	SpecialPurposes() []Definition
+	Feature(id uint8) (Definition, bool)
+	Features() []Definition
+	SpecialFeature(id consentconstants.SpecialFeature) (Definition, bool)
+	SpecialFeatures() []Definition
+}
+
+// Definition describes a purpose, special purpose, feature or special feature to users.
+type Definition struct {
+	ID          uint8  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Illustrations are examples of the processing, which vendor lists of specification version 3 and later include.
+	Illustrations []string `json:"illustrations"`
+}
+
+// VendorListStacks gives the stacks of a vendor list, which group purposes and special features so that CMPs
+// can present them together. The vendor lists of the vendorlist2 package implement it.
+type VendorListStacks interface {
+	VendorList
+
+	// Stack returns the stack with the given ID. The second return value is false if the vendor list
+	// doesn't define it.
+	Stack(id uint8) (Stack, bool)
+	// Stacks returns every stack, sorted by ID.
+	Stacks() []Stack
+}
+
+// Stack is a group of purposes and special features, which CMPs can present to users as a whole.
+type Stack struct {
+	ID              uint8                             `json:"id"`
+	Name            string                            `json:"name"`
+	Description     string                            `json:"description"`
+	Purposes        []consentconstants.Purpose        `json:"purposes"`
+	SpecialFeatures []consentconstants.SpecialFeature `json:"specialFeatures"`
+}
@@ -0,0 +1,101 @@
+package bitutils
+
+import (
+	"fmt"
+	"time"
+)
+
+// BitWriter builds a byte array bit by bit. Values are written with their most significant bit first,
+// which is the order the consent string formats use. The zero value is an empty BitWriter ready to use.
+type BitWriter struct {
+	data   []byte
+	bitLen uint
+}
+
+// WriteBool writes a single bit, which is 1 if the value is true and 0 otherwise
+func (w *BitWriter) WriteBool(value bool) {
+	if w.bitLen%8 == 0 {
+		w.data = append(w.data, 0)
+	}
+	if value {
+		w.data[w.bitLen/8] |= 0x80 >> (w.bitLen % 8)
+	}
+	w.bitLen++
+}
+
+// WriteBits writes the bitCount least significant bits of the value. The other bits of the value are ignored.
+func (w *BitWriter) WriteBits(value uint64, bitCount uint) {
+	for i := bitCount; i > 0; i-- {
+		w.WriteBool((value>>(i-1))&0x01 == 1)
+	}
+}
+
+// Len returns the number of bits written so far
+func (w *BitWriter) Len() uint {
+	return w.bitLen
+}
+
+// Bytes returns the data written so far. If the number of bits written isn't a multiple of 8,
+// the last byte is padded with zeros.
+func (w *BitWriter) Bytes() []byte {
+	return w.data
+}
+
+// WriteTimestamp writes the number of deciseconds since the Unix epoch in 36 bits, like the Created and
+// LastUpdated fields of consent strings. The zero time is written as the epoch. The field names the value in errors.
+func (w *BitWriter) WriteTimestamp(field string, value time.Time) error {
+	var deciseconds int64
+	if !value.IsZero() {
+		deciseconds = value.Unix()*10 + int64(value.Nanosecond())/100000000
+	}
+	if deciseconds < 0 || deciseconds >= 1<<36 {
+		return fmt.Errorf("%s is %s, which can't be stored in a consent string", field, value)
+	}
+	w.WriteBits(uint64(deciseconds), 36)
+	return nil
+}
+
+// WriteLetters writes a two-letter code, with 6 bits per letter and A=0. Lower case letters are written as upper case ones.
+// The field names the value in errors.
+func (w *BitWriter) WriteLetters(field string, value string) error {
+	if len(value) != 2 {
+		return fmt.Errorf("%s must be two letters long. Got %q", field, value)
+	}
+	for i := 0; i < 2; i++ {
+		letter := value[i]
+		if letter >= 'a' && letter <= 'z' {
+			letter = letter - 'a' + 'A'
+		}
+		if letter < 'A' || letter > 'Z' {
+			return fmt.Errorf("%s must only contain the letters A to Z. Got %q", field, value)
+		}
+		w.WriteBits(uint64(letter-'A'), 6)
+	}
+	return nil
+}
+
+// WriteRawLetters writes a two-letter code read from a consent string back as it was. Unlike WriteLetters, it keeps
+// the 6-bit values 26 to 63, which parsers return as the characters after 'Z', and doesn't change the case of letters.
+// The field names the value in errors.
+func (w *BitWriter) WriteRawLetters(field string, value string) error {
+	if len(value) != 2 {
+		return fmt.Errorf("%s must be two characters long. Got %q", field, value)
+	}
+	for i := 0; i < 2; i++ {
+		if value[i] < 'A' || value[i] > 'A'+63 {
+			return fmt.Errorf("%s must only contain characters which encode 6-bit values. Got %q", field, value)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		w.WriteBits(uint64(value[i]-'A'), 6)
+	}
+	return nil
+}
+
+// CheckMaxValue returns an error if the value doesn't fit in bitCount bits. The field names the value in the error.
+func CheckMaxValue(field string, value uint64, bitCount uint) error {
+	if value >= 1<<bitCount {
+		return fmt.Errorf("%s is %d, but the consent string only has room for values up to %d", field, value, uint64(1)<<bitCount-1)
+	}
+	return nil
+}
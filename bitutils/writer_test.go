@@ -0,0 +1,102 @@
+package bitutils
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestBitWriter(t *testing.T) {
+	var w BitWriter
+	assertIntsEqual(t, 0, len(w.Bytes()))
+
+	// Rebuild testdata: 0000 0100 1010 0010 0000 0011 1011 0001 0000 0000 0010 1011
+	w.WriteBits(0, 5)
+	w.WriteBool(true)
+	w.WriteBits(0x28, 8)
+	w.WriteBits(0x203, 10)
+	w.WriteBits(0xffb1, 8) // Only the 8 least significant bits are written
+	w.WriteBits(0x002b, 16)
+	assertIntsEqual(t, 48, int(w.Len()))
+	if !bytes.Equal(testdata, w.Bytes()) {
+		t.Errorf("Bytes were not equal. Expected %x, actual %x", testdata, w.Bytes())
+	}
+}
+
+func TestBitWriterPadding(t *testing.T) {
+	var w BitWriter
+	w.WriteBool(true)
+	w.WriteBits(3, 2)
+	assertIntsEqual(t, 3, int(w.Len()))
+	assertIntsEqual(t, 1, len(w.Bytes()))
+	assertBytesEqual(t, 0xe0, w.Bytes()[0])
+}
+
+func TestBitWriterRoundTrip(t *testing.T) {
+	var w BitWriter
+	for _, test := range test16Bits {
+		w.WriteBits(test.value, 16)
+	}
+	for i, test := range test16Bits {
+		value, err := ParseUInt16(w.Bytes(), uint(i)*16)
+		assertNilError(t, err)
+		assertUInt16sEqual(t, uint16(test.value), value)
+	}
+}
+
+func TestBitWriterWriteTimestamp(t *testing.T) {
+	var w BitWriter
+	assertNilError(t, w.WriteTimestamp("Created", time.Unix(1, 250000000)))
+	assertNilError(t, w.WriteTimestamp("LastUpdated", time.Time{}))
+	assertIntsEqual(t, 72, int(w.Len()))
+
+	// 12 deciseconds in 36 bits, then 36 zero bits for the zero time
+	if !bytes.Equal([]byte{0x00, 0x00, 0x00, 0x00, 0xc0, 0x00, 0x00, 0x00, 0x00}, w.Bytes()) {
+		t.Errorf("Bytes were not equal. Actual %x", w.Bytes())
+	}
+
+	if err := w.WriteTimestamp("Created", time.Unix(-1, 0)); err == nil {
+		t.Error("Timestamps before the epoch should be rejected")
+	}
+	if err := w.WriteTimestamp("Created", time.Unix(1<<36/10+1, 0)); err == nil {
+		t.Error("Timestamps which don't fit in 36 bits should be rejected")
+	}
+}
+
+func TestBitWriterWriteLetters(t *testing.T) {
+	var w BitWriter
+	assertNilError(t, w.WriteLetters("ConsentLanguage", "eN"))
+	assertIntsEqual(t, 12, int(w.Len()))
+	value, err := ParseUInt12(w.Bytes(), 0)
+	assertNilError(t, err)
+	assertUInt16sEqual(t, 4<<6|13, value)
+
+	for _, invalid := range []string{"E", "ENG", "E1"} {
+		if err := w.WriteLetters("ConsentLanguage", invalid); err == nil {
+			t.Errorf("%q should be rejected", invalid)
+		}
+	}
+}
+
+func TestBitWriterWriteRawLetters(t *testing.T) {
+	var w BitWriter
+	assertNilError(t, w.WriteRawLetters("PublisherCC", string([]byte{'a', 'A' + 63})))
+	value, err := ParseUInt12(w.Bytes(), 0)
+	assertNilError(t, err)
+	assertUInt16sEqual(t, 32<<6|63, value)
+
+	for _, invalid := range []string{"E", "E1", string([]byte{'E', 'A' + 64})} {
+		if err := w.WriteRawLetters("PublisherCC", invalid); err == nil {
+			t.Errorf("%q should be rejected", invalid)
+		}
+	}
+}
+
+func TestCheckMaxValue(t *testing.T) {
+	assertNilError(t, CheckMaxValue("CmpID", 4095, 12))
+	err := CheckMaxValue("CmpID", 4096, 12)
+	if err == nil {
+		t.Fatal("4096 should not fit in 12 bits")
+	}
+	assertStringsEqual(t, "CmpID is 4096, but the consent string only has room for values up to 4095", err.Error())
+}
@@ -0,0 +1,49 @@
+package consentconstants
+
+import "fmt"
+
+// LegalBasis is the legal basis which lets a vendor process personal data for a purpose.
+// It is encoded in JSON and other text formats as "none", "consent" or "legitimate_interest".
+type LegalBasis uint8
+
+const (
+	// LegalBasisNone means that neither consent nor legitimate interest was established.
+	LegalBasisNone LegalBasis = iota
+	// LegalBasisConsent means that the user consented to the processing.
+	LegalBasisConsent
+	// LegalBasisLegitimateInterest means that legitimate interest was established, and the user didn't object to it.
+	LegalBasisLegitimateInterest
+)
+
+var legalBasisNames = map[LegalBasis]string{
+	LegalBasisNone:               "none",
+	LegalBasisConsent:            "consent",
+	LegalBasisLegitimateInterest: "legitimate_interest",
+}
+
+func (b LegalBasis) String() string {
+	if name, ok := legalBasisNames[b]; ok {
+		return name
+	}
+	return "undefined"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b LegalBasis) MarshalText() ([]byte, error) {
+	name, ok := legalBasisNames[b]
+	if !ok {
+		return nil, fmt.Errorf("legal basis %d is undefined", uint8(b))
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *LegalBasis) UnmarshalText(text []byte) error {
+	for basis, name := range legalBasisNames {
+		if name == string(text) {
+			*b = basis
+			return nil
+		}
+	}
+	return fmt.Errorf("legal basis %q is undefined", string(text))
+}
@@ -0,0 +1,332 @@
+// Package enforcement applies the TCF 2 policies to decide whether a vendor may process personal data for a purpose.
+// It combines the values of the consent string, the publisher restrictions and the purposes which each vendor
+// declared in the Global Vendor List.
+package enforcement
+
+import (
+	"github.com/prebid/go-gdpr/api"
+	"github.com/prebid/go-gdpr/consentconstants"
+	tcf2 "github.com/prebid/go-gdpr/vendorconsent/tcf2"
+)
+
+// Decision is the outcome of an enforcement check.
+// Decisions can be serialized to JSON, to keep a record of them.
+type Decision struct {
+	// Allowed is true if the vendor may process personal data for the purpose.
+	Allowed bool `json:"allowed"`
+	// LegalBasis is the legal basis which allowed the processing. This is LegalBasisNone if Allowed is false,
+	// or if the processing relies on something else than the consent string.
+	LegalBasis consentconstants.LegalBasis `json:"legalBasis"`
+	// Reason explains why the processing was allowed or denied.
+	Reason Reason `json:"reason"`
+	// Trace lists every input which the check consulted, in order. The last step which denied the processing,
+	// if any, is the one which Reason refers to.
+	Trace []Step `json:"trace"`
+}
+
+// Reason is a stable code which explains a Decision.
+type Reason string
+
+// Reasons for allowing the processing:
+const (
+	// ReasonConsent means that the user consented to both the purpose and the vendor.
+	ReasonConsent Reason = "consent"
+	// ReasonLegitimateInterest means that legitimate interest was established for both the purpose and the vendor.
+	ReasonLegitimateInterest Reason = "legitimate_interest"
+	// ReasonPurposeOneTreatment means that purpose 1 wasn't disclosed to the user, but the policy of
+	// the publisher's country allows storing and accessing information on the device anyway.
+	ReasonPurposeOneTreatment Reason = "purpose_one_treatment"
+	// ReasonSpecialFeatureOptIn means that the user opted in to the special feature.
+	ReasonSpecialFeatureOptIn Reason = "special_feature_opt_in"
+	// ReasonPurposeNotEnforced means that the Policy disables the enforcement of the purpose.
+	ReasonPurposeNotEnforced Reason = "purpose_not_enforced"
+	// ReasonVendorException means that the Policy exempts the vendor from the enforcement of the purpose.
+	ReasonVendorException Reason = "vendor_exception"
+)
+
+// Reasons for denying the processing:
+const (
+	// ReasonUnsupportedConsent means that the consent string isn't a TCF 2 consent string.
+	ReasonUnsupportedConsent Reason = "unsupported_consent_string"
+	// ReasonInvalidPurpose means that the purpose isn't in [1, 24].
+	ReasonInvalidPurpose Reason = "invalid_purpose"
+	// ReasonVendorNotInGVL means that the vendor isn't in the Global Vendor List.
+	ReasonVendorNotInGVL Reason = "vendor_not_in_gvl"
+	// ReasonPubRestrictNotAllowed means that the publisher doesn't allow the vendor to use the purpose.
+	ReasonPubRestrictNotAllowed Reason = "publisher_restriction_not_allowed"
+	// ReasonPubRestrictLegalBasis means that the publisher requires a legal basis for the purpose
+	// which the vendor can't use, because it didn't declare the purpose as flexible.
+	ReasonPubRestrictLegalBasis Reason = "publisher_restriction_legal_basis"
+	// ReasonPurposeNotDeclared means that the vendor didn't declare the purpose in the Global Vendor List.
+	ReasonPurposeNotDeclared Reason = "purpose_not_declared"
+	// ReasonNoPurposeConsent means that the user didn't consent to the purpose.
+	ReasonNoPurposeConsent Reason = "no_purpose_consent"
+	// ReasonNoVendorConsent means that the user didn't consent to the vendor.
+	ReasonNoVendorConsent Reason = "no_vendor_consent"
+	// ReasonNoPurposeLegitimateInterest means that legitimate interest wasn't disclosed for the purpose,
+	// or that the user objected to it.
+	ReasonNoPurposeLegitimateInterest Reason = "no_purpose_legitimate_interest"
+	// ReasonNoVendorLegitimateInterest means that the user objected to the vendor's legitimate interest.
+	ReasonNoVendorLegitimateInterest Reason = "no_vendor_legitimate_interest"
+	// ReasonLegitimateInterestNotAllowed means that the RuleSet doesn't allow legitimate interest for the purpose,
+	// and that the vendor can't rely on consent instead.
+	ReasonLegitimateInterestNotAllowed Reason = "legitimate_interest_not_allowed"
+	// ReasonPurposeOneNotDisclosed means that purpose 1 wasn't disclosed to the user, and the policy of
+	// the publisher's country doesn't allow storing or accessing information on the device without that.
+	ReasonPurposeOneNotDisclosed Reason = "purpose_one_not_disclosed"
+	// ReasonInvalidSpecialFeature means that the special feature isn't in [1, 12].
+	ReasonInvalidSpecialFeature Reason = "invalid_special_feature"
+	// ReasonSpecialFeatureNotDeclared means that the vendor didn't declare the special feature in the Global Vendor List.
+	ReasonSpecialFeatureNotDeclared Reason = "special_feature_not_declared"
+	// ReasonNoSpecialFeatureOptIn means that the user didn't opt in to the special feature.
+	ReasonNoSpecialFeatureOptIn Reason = "no_special_feature_opt_in"
+	// ReasonVendorNotDisclosed means that the consent string follows the TCF policy version 4 or later, and its
+	// Disclosed Vendors segment shows that the vendor wasn't disclosed to the user.
+	ReasonVendorNotDisclosed Reason = "vendor_not_disclosed"
+	// ReasonNoLegalBasis means that, under ModeBasic, the consent string establishes neither consent
+	// nor legitimate interest for both the purpose and the vendor.
+	ReasonNoLegalBasis Reason = "no_legal_basis"
+)
+
+// Enforcer applies the TCF 2 policies. The zero value is ready to use, and can be shared safely between goroutines
+// as long as its fields aren't modified.
+type Enforcer struct {
+	// PurposeOnePolicies maps the two-letter country codes of publishers, in uppercase, to the policy which applies
+	// when a consent string sets PurposeOneTreatment. Countries which aren't listed get PurposeOneDeny.
+	PurposeOnePolicies map[string]PurposeOnePolicy
+	// Policy configures the enforcement of each purpose. The zero value enforces every purpose fully.
+	Policy Policy
+	// RuleSets picks the policies which apply to each consent string and vendor list. If nil, SelectRuleSet does.
+	RuleSets RuleSetSelector
+}
+
+// PurposeOnePolicy says whether vendors may store or access information on a device when purpose 1
+// wasn't disclosed to the user, which consent strings signal with PurposeOneTreatment.
+type PurposeOnePolicy uint8
+
+const (
+	// PurposeOneDeny doesn't allow storing or accessing information on the device without disclosing purpose 1.
+	PurposeOneDeny PurposeOnePolicy = iota
+	// PurposeOneAllow allows storing and accessing information on the device, because the publisher's country
+	// doesn't require consent for it.
+	PurposeOneAllow
+)
+
+func (p PurposeOnePolicy) String() string {
+	switch p {
+	case PurposeOneDeny:
+		return "deny"
+	case PurposeOneAllow:
+		return "allow"
+	}
+	return "undefined"
+}
+
+// CheckDeviceStorage decides whether the vendor may store or access information on the device, which is purpose 1.
+//
+// If the consent string sets PurposeOneTreatment, purpose 1 wasn't disclosed to the user. The policy of the
+// publisher's country, from PurposeOnePolicies, decides then. An allowed Decision has no legal basis in that case,
+// since the processing relies on the law of the publisher's country rather than on the consent string.
+// Otherwise, this is the same as CheckPurpose for purpose 1.
+func (e Enforcer) CheckDeviceStorage(consent api.VendorConsents, vendorList api.VendorList, vendorID uint16) Decision {
+	return e.CheckPurpose(consent, vendorList, vendorID, consentconstants.InfoStorageAccess)
+}
+
+// CheckPurpose decides whether the vendor may process personal data for the purpose.
+// Publisher restrictions of type 1 and 2 pick the legal basis of flexible purposes, as described by tcf2.EffectiveLegalBasis.
+// Purpose 1 follows the rules of CheckDeviceStorage.
+//
+// The Policy may disable the purpose, exempt the vendor, or use ModeBasic, which ignores the vendorList.
+// Otherwise, consent strings of TCF policy version 4 or later which include the Disclosed Vendors segment
+// give no legal basis to the vendors which weren't disclosed to the user.
+//
+// The RuleSet of the consent string's TCFPolicyVersion and the vendor list's SpecVersion says for which purposes
+// legitimate interest is forbidden. Flexible purposes fall back to consent then.
+//
+// The consent must be a TCF 2 consent string, such as the ones returned by vendorconsent.ParseString.
+// The vendorList should be the version of the Global Vendor List returned by consent.VendorListVersion().
+func (e Enforcer) CheckPurpose(consent api.VendorConsents, vendorList api.VendorList, vendorID uint16, purpose consentconstants.Purpose) Decision {
+	return e.checkPurpose(&tracer{}, consent, vendorList, vendorID, purpose)
+}
+
+func (e Enforcer) checkPurpose(t *tracer, consent api.VendorConsents, vendorList api.VendorList, vendorID uint16, purpose consentconstants.Purpose) Decision {
+	tcf2Consent, ok := t.tcf2Consent(consent)
+	if !ok {
+		return t.deny(ReasonUnsupportedConsent)
+	}
+	if purpose < 1 || purpose > 24 {
+		t.record(InputPurpose, purpose, EffectDenied)
+		return t.deny(ReasonInvalidPurpose)
+	}
+
+	// Purposes which the Policy doesn't configure are enforced fully, without cluttering the trace
+	policy, configured := e.Policy.purpose(purpose)
+	if configured {
+		if policy.Disabled {
+			t.record(InputPolicyEnabled, false, EffectSatisfied)
+			return Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisNone, Reason: ReasonPurposeNotEnforced, Trace: t.steps}
+		}
+		t.record(InputPolicyEnabled, true, EffectInformational)
+		if policy.isException(vendorID) {
+			t.record(InputPolicyVendorException, true, EffectSatisfied)
+			return Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisNone, Reason: ReasonVendorException, Trace: t.steps}
+		}
+		t.record(InputPolicyVendorException, false, EffectInformational)
+		t.record(InputPolicyMode, policy.Mode, EffectInformational)
+	}
+	if !t.disclosed(tcf2Consent, vendorID) {
+		return t.deny(ReasonVendorNotDisclosed)
+	}
+	if policy.Mode == ModeBasic {
+		return e.checkBasic(t, tcf2Consent, vendorID, purpose)
+	}
+
+	vendor := t.vendor(vendorList, vendorID)
+	if vendor == nil {
+		return t.deny(ReasonVendorNotInGVL)
+	}
+	if decision, done := e.checkRestrictionAndPurposeOne(t, tcf2Consent, vendorID, purpose); done {
+		return decision
+	}
+
+	declared := consentconstants.LegalBasisNone
+	if vendor.PurposeStrict(purpose) {
+		declared = consentconstants.LegalBasisConsent
+	} else if vendor.LegitimateInterestStrict(purpose) {
+		declared = consentconstants.LegalBasisLegitimateInterest
+	}
+	if declared == consentconstants.LegalBasisNone {
+		t.record(InputGVLDeclaration, declared, EffectDenied)
+		return t.deny(ReasonPurposeNotDeclared)
+	}
+	t.record(InputGVLDeclaration, declared, EffectInformational)
+	flexible := vendor.Purpose(purpose) && vendor.LegitimateInterest(purpose)
+	t.record(InputGVLFlexible, flexible, EffectInformational)
+
+	effective := tcf2.EffectiveLegalBasis(tcf2Consent, vendorID, vendor, purpose)
+	if effective == consentconstants.LegalBasisLegitimateInterest {
+		rules := e.ruleSet(tcf2Consent.TCFPolicyVersion(), vendorList.SpecVersion())
+		if !rules.LegitimateInterestAllowed(purpose) {
+			// Flexible purposes fall back to consent, unless the publisher requires legitimate interest
+			if !flexible || tcf2Consent.VendorPubRestrictions(vendorID)[uint8(purpose)] == tcf2.PubRestrictRequireLI {
+				t.record(InputRuleSet, rules.Name(), EffectDenied)
+				return t.deny(ReasonLegitimateInterestNotAllowed)
+			}
+			t.record(InputRuleSet, rules.Name(), EffectInformational)
+			effective = consentconstants.LegalBasisConsent
+		}
+	}
+	switch effective {
+	case consentconstants.LegalBasisConsent:
+		t.record(InputEffectiveLegalBasis, effective, EffectInformational)
+		return checkConsent(t, tcf2Consent, vendorID, purpose)
+	case consentconstants.LegalBasisLegitimateInterest:
+		t.record(InputEffectiveLegalBasis, effective, EffectInformational)
+		return checkLegitimateInterest(t, tcf2Consent, vendorID, purpose)
+	}
+	t.record(InputEffectiveLegalBasis, effective, EffectDenied)
+	return t.deny(ReasonPubRestrictLegalBasis)
+}
+
+// checkBasic applies ModeBasic, which relies on the consent string alone
+func (e Enforcer) checkBasic(t *tracer, consent tcf2.VendorConsents, vendorID uint16, purpose consentconstants.Purpose) Decision {
+	if decision, done := e.checkRestrictionAndPurposeOne(t, consent, vendorID, purpose); done {
+		return decision
+	}
+	if t.require(InputPurposeConsent, consent.PurposeAllowed(purpose)) && t.require(InputVendorConsent, consent.VendorConsent(vendorID)) {
+		return Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent, Trace: t.steps}
+	}
+	if rules := e.ruleSet(consent.TCFPolicyVersion(), 0); !rules.LegitimateInterestAllowed(purpose) {
+		t.record(InputRuleSet, rules.Name(), EffectDenied)
+		return t.deny(ReasonNoLegalBasis)
+	}
+	if t.require(InputPurposeLITransparency, consent.PurposeLITransparency(purpose)) &&
+		t.require(InputVendorLegitInterest, consent.VendorLegitInterest(vendorID)) {
+		return Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisLegitimateInterest, Reason: ReasonLegitimateInterest, Trace: t.steps}
+	}
+	return t.deny(ReasonNoLegalBasis)
+}
+
+// checkRestrictionAndPurposeOne applies the publisher restrictions which don't allow the purpose, and the
+// PurposeOneTreatment. It returns true if either of them decided.
+func (e Enforcer) checkRestrictionAndPurposeOne(t *tracer, consent tcf2.VendorConsents, vendorID uint16, purpose consentconstants.Purpose) (Decision, bool) {
+	if restrictType, restricted := consent.VendorPubRestrictions(vendorID)[uint8(purpose)]; !restricted {
+		t.record(InputPubRestriction, nil, EffectInformational)
+	} else if restrictType == tcf2.PubRestrictNotAllowed {
+		t.record(InputPubRestriction, restrictType, EffectDenied)
+		return t.deny(ReasonPubRestrictNotAllowed), true
+	} else {
+		t.record(InputPubRestriction, restrictType, EffectInformational)
+	}
+
+	if purpose == consentconstants.InfoStorageAccess {
+		if purposeOneTreatment := consent.PurposeOneTreatment(); purposeOneTreatment {
+			t.record(InputPurposeOneTreatment, purposeOneTreatment, EffectInformational)
+			return e.checkPurposeOneTreatment(t, consent), true
+		}
+		t.record(InputPurposeOneTreatment, false, EffectInformational)
+	}
+	return Decision{}, false
+}
+
+// CheckSpecialFeature decides whether the vendor may use the special feature, such as the ones
+// in the consentconstants/tcf2 package. This requires the vendor to declare the special feature in
+// the Global Vendor List, and the user to opt in to it. An allowed Decision has LegalBasisConsent,
+// since the opt-in is the only legal basis for special features.
+func (e Enforcer) CheckSpecialFeature(consent api.VendorConsents, vendorList api.VendorList, vendorID uint16, feature consentconstants.SpecialFeature) Decision {
+	var t tracer
+	tcf2Consent, ok := t.tcf2Consent(consent)
+	if !ok {
+		return t.deny(ReasonUnsupportedConsent)
+	}
+	if feature < 1 || feature > 12 {
+		t.record(InputSpecialFeature, feature, EffectDenied)
+		return t.deny(ReasonInvalidSpecialFeature)
+	}
+	if !t.disclosed(tcf2Consent, vendorID) {
+		return t.deny(ReasonVendorNotDisclosed)
+	}
+	vendor := t.vendor(vendorList, vendorID)
+	if vendor == nil {
+		return t.deny(ReasonVendorNotInGVL)
+	}
+	if !t.require(InputGVLSpecialFeature, vendor.SpecialFeature(feature)) {
+		return t.deny(ReasonSpecialFeatureNotDeclared)
+	}
+	if !t.require(InputSpecialFeatureOptIn, tcf2Consent.SpecialFeatureOptIn(uint16(feature))) {
+		return t.deny(ReasonNoSpecialFeatureOptIn)
+	}
+	return Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonSpecialFeatureOptIn, Trace: t.steps}
+}
+
+func (e Enforcer) checkPurposeOneTreatment(t *tracer, consent tcf2.VendorConsents) Decision {
+	publisherCC := consent.PublisherCC()
+	t.record(InputPublisherCC, publisherCC, EffectInformational)
+	policy := e.PurposeOnePolicies[publisherCC]
+	if policy != PurposeOneAllow {
+		t.record(InputPurposeOnePolicy, policy.String(), EffectDenied)
+		return t.deny(ReasonPurposeOneNotDisclosed)
+	}
+	t.record(InputPurposeOnePolicy, policy.String(), EffectSatisfied)
+	return Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisNone, Reason: ReasonPurposeOneTreatment, Trace: t.steps}
+}
+
+func checkConsent(t *tracer, consent tcf2.VendorConsents, vendorID uint16, purpose consentconstants.Purpose) Decision {
+	if !t.require(InputPurposeConsent, consent.PurposeAllowed(purpose)) {
+		return t.deny(ReasonNoPurposeConsent)
+	}
+	if !t.require(InputVendorConsent, consent.VendorConsent(vendorID)) {
+		return t.deny(ReasonNoVendorConsent)
+	}
+	return Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent, Trace: t.steps}
+}
+
+func checkLegitimateInterest(t *tracer, consent tcf2.VendorConsents, vendorID uint16, purpose consentconstants.Purpose) Decision {
+	if !t.require(InputPurposeLITransparency, consent.PurposeLITransparency(purpose)) {
+		return t.deny(ReasonNoPurposeLegitimateInterest)
+	}
+	if !t.require(InputVendorLegitInterest, consent.VendorLegitInterest(vendorID)) {
+		return t.deny(ReasonNoVendorLegitimateInterest)
+	}
+	return Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisLegitimateInterest, Reason: ReasonLegitimateInterest, Trace: t.steps}
+}
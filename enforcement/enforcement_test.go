@@ -0,0 +1,510 @@
+package enforcement
+
+import (
+	"testing"
+
+	"github.com/prebid/go-gdpr/api"
+	"github.com/prebid/go-gdpr/consentconstants"
+	tcf2constants "github.com/prebid/go-gdpr/consentconstants/tcf2"
+	tcf1 "github.com/prebid/go-gdpr/vendorconsent/tcf1"
+	tcf2 "github.com/prebid/go-gdpr/vendorconsent/tcf2"
+	"github.com/prebid/go-gdpr/vendorlist2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVendorList declares:
+//
+//	vendor 1: consent for purposes 1, 2 and 3, legitimate interest for purpose 7
+//	vendor 2: legitimate interest for purposes 1 and 2
+//	vendor 3: consent for purpose 4
+//	vendor 4: flexible purposes 2 (consent by default) and 3 (legitimate interest by default)
+//	vendor 5: consent for purpose 2, and both special features
+const testVendorList = `{
+	"gvlSpecificationVersion": 2,
+	"vendorListVersion": 10,
+	"vendors": {
+		"1": {"id": 1, "purposes": [1, 2, 3], "legIntPurposes": [7], "flexiblePurposes": [], "specialPurposes": [], "specialFeatures": []},
+		"2": {"id": 2, "purposes": [], "legIntPurposes": [1, 2], "flexiblePurposes": [], "specialPurposes": [], "specialFeatures": []},
+		"3": {"id": 3, "purposes": [4], "legIntPurposes": [], "flexiblePurposes": [], "specialPurposes": [], "specialFeatures": []},
+		"4": {"id": 4, "purposes": [2], "legIntPurposes": [3], "flexiblePurposes": [2, 3], "specialPurposes": [], "specialFeatures": []},
+		"5": {"id": 5, "purposes": [2], "legIntPurposes": [], "flexiblePurposes": [], "specialPurposes": [], "specialFeatures": [1, 2]}
+	}
+}`
+
+func TestCheckPurpose(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  tcf2.ConsentBuilder
+		vendorID uint16
+		purpose  consentconstants.Purpose
+		expected Decision
+	}{
+		{
+			name:     "consent",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(1, 2), VendorConsents: []uint16{1}},
+			vendorID: 1,
+			purpose:  2,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent},
+		},
+		{
+			name:     "no_purpose_consent",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(1), VendorConsents: []uint16{1}},
+			vendorID: 1,
+			purpose:  2,
+			expected: Decision{Reason: ReasonNoPurposeConsent},
+		},
+		{
+			name:     "no_vendor_consent",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(2), VendorConsents: []uint16{3}},
+			vendorID: 1,
+			purpose:  2,
+			expected: Decision{Reason: ReasonNoVendorConsent},
+		},
+		{
+			name:     "consent_ignores_legitimate_interest",
+			builder:  tcf2.ConsentBuilder{PurposesLITransparency: purposes(2), VendorLegitimateInterests: []uint16{1}},
+			vendorID: 1,
+			purpose:  2,
+			expected: Decision{Reason: ReasonNoPurposeConsent},
+		},
+		{
+			name:     "legitimate_interest",
+			builder:  tcf2.ConsentBuilder{PurposesLITransparency: purposes(7), VendorLegitimateInterests: []uint16{1}},
+			vendorID: 1,
+			purpose:  7,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisLegitimateInterest, Reason: ReasonLegitimateInterest},
+		},
+		{
+			name:     "no_purpose_legitimate_interest",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(7), VendorLegitimateInterests: []uint16{1}},
+			vendorID: 1,
+			purpose:  7,
+			expected: Decision{Reason: ReasonNoPurposeLegitimateInterest},
+		},
+		{
+			name:     "no_vendor_legitimate_interest",
+			builder:  tcf2.ConsentBuilder{PurposesLITransparency: purposes(7), VendorConsents: []uint16{1}},
+			vendorID: 1,
+			purpose:  7,
+			expected: Decision{Reason: ReasonNoVendorLegitimateInterest},
+		},
+		{
+			name:     "legitimate_interest_never_covers_purpose_1",
+			builder:  tcf2.ConsentBuilder{PurposesLITransparency: purposes(1), VendorLegitimateInterests: []uint16{2}},
+			vendorID: 2,
+			purpose:  1,
+			expected: Decision{Reason: ReasonLegitimateInterestNotAllowed},
+		},
+		{
+			name:     "purpose_not_declared",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(5), VendorConsents: []uint16{3}},
+			vendorID: 3,
+			purpose:  5,
+			expected: Decision{Reason: ReasonPurposeNotDeclared},
+		},
+		{
+			name:     "vendor_not_in_gvl",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(2), VendorConsents: []uint16{6}},
+			vendorID: 6,
+			purpose:  2,
+			expected: Decision{Reason: ReasonVendorNotInGVL},
+		},
+		{
+			name:     "invalid_purpose",
+			builder:  tcf2.ConsentBuilder{VendorConsents: []uint16{1}},
+			vendorID: 1,
+			purpose:  25,
+			expected: Decision{Reason: ReasonInvalidPurpose},
+		},
+		{
+			name: "publisher_restriction_not_allowed",
+			builder: tcf2.ConsentBuilder{
+				PurposesConsent: purposes(2),
+				VendorConsents:  []uint16{1},
+				PubRestrictions: []tcf2.PubRestriction{
+					{PurposeID: 2, RestrictType: tcf2.PubRestrictNotAllowed, Vendors: []tcf2.VendorRange{{StartID: 1, EndID: 1}}},
+				},
+			},
+			vendorID: 1,
+			purpose:  2,
+			expected: Decision{Reason: ReasonPubRestrictNotAllowed},
+		},
+		{
+			name: "publisher_restriction_on_other_vendor",
+			builder: tcf2.ConsentBuilder{
+				PurposesConsent: purposes(2),
+				VendorConsents:  []uint16{1},
+				PubRestrictions: []tcf2.PubRestriction{
+					{PurposeID: 2, RestrictType: tcf2.PubRestrictNotAllowed, Vendors: []tcf2.VendorRange{{StartID: 2, EndID: 3}}},
+				},
+			},
+			vendorID: 1,
+			purpose:  2,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent},
+		},
+		{
+			name: "flexible_purpose_require_legitimate_interest",
+			builder: tcf2.ConsentBuilder{
+				PurposesConsent:           purposes(2),
+				PurposesLITransparency:    purposes(2),
+				VendorConsents:            []uint16{4},
+				VendorLegitimateInterests: []uint16{4},
+				PubRestrictions: []tcf2.PubRestriction{
+					{PurposeID: 2, RestrictType: tcf2.PubRestrictRequireLI, Vendors: []tcf2.VendorRange{{StartID: 4, EndID: 4}}},
+				},
+			},
+			vendorID: 4,
+			purpose:  2,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisLegitimateInterest, Reason: ReasonLegitimateInterest},
+		},
+		{
+			name: "flexible_purpose_require_consent_without_consent",
+			builder: tcf2.ConsentBuilder{
+				PurposesLITransparency:    purposes(3),
+				VendorLegitimateInterests: []uint16{4},
+				PubRestrictions: []tcf2.PubRestriction{
+					{PurposeID: 3, RestrictType: tcf2.PubRestrictRequireConsent, Vendors: []tcf2.VendorRange{{StartID: 4, EndID: 4}}},
+				},
+			},
+			vendorID: 4,
+			purpose:  3,
+			expected: Decision{Reason: ReasonNoPurposeConsent},
+		},
+		{
+			name: "flexible_purpose_default_legitimate_interest",
+			builder: tcf2.ConsentBuilder{
+				PurposesLITransparency:    purposes(3),
+				VendorLegitimateInterests: []uint16{4},
+			},
+			vendorID: 4,
+			purpose:  3,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisLegitimateInterest, Reason: ReasonLegitimateInterest},
+		},
+		{
+			name: "fixed_purpose_require_other_legal_basis",
+			builder: tcf2.ConsentBuilder{
+				PurposesConsent:           purposes(7),
+				PurposesLITransparency:    purposes(7),
+				VendorConsents:            []uint16{1},
+				VendorLegitimateInterests: []uint16{1},
+				PubRestrictions: []tcf2.PubRestriction{
+					{PurposeID: 7, RestrictType: tcf2.PubRestrictRequireConsent, Vendors: []tcf2.VendorRange{{StartID: 1, EndID: 1}}},
+				},
+			},
+			vendorID: 1,
+			purpose:  7,
+			expected: Decision{Reason: ReasonPubRestrictLegalBasis},
+		},
+	}
+
+	vendorList := parseVendorList(t, testVendorList)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consent := buildConsent(t, tt.builder)
+			var enforcer Enforcer
+			assert.Equal(t, tt.expected, withoutTrace(enforcer.CheckPurpose(consent, vendorList, tt.vendorID, tt.purpose)))
+		})
+	}
+}
+
+func TestCheckDeviceStorage(t *testing.T) {
+	enforcer := Enforcer{
+		PurposeOnePolicies: map[string]PurposeOnePolicy{
+			"DE": PurposeOneAllow,
+			"FR": PurposeOneDeny,
+		},
+	}
+	tests := []struct {
+		name     string
+		builder  tcf2.ConsentBuilder
+		vendorID uint16
+		expected Decision
+	}{
+		{
+			name:     "disclosed_with_consent",
+			builder:  tcf2.ConsentBuilder{PublisherCC: "DE", PurposesConsent: purposes(1), VendorConsents: []uint16{1}},
+			vendorID: 1,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent},
+		},
+		{
+			name:     "disclosed_without_consent",
+			builder:  tcf2.ConsentBuilder{PublisherCC: "DE", VendorConsents: []uint16{1}},
+			vendorID: 1,
+			expected: Decision{Reason: ReasonNoPurposeConsent},
+		},
+		{
+			name:     "not_disclosed_country_allows",
+			builder:  tcf2.ConsentBuilder{PublisherCC: "DE", PurposeOneTreatment: true},
+			vendorID: 1,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisNone, Reason: ReasonPurposeOneTreatment},
+		},
+		{
+			name:     "not_disclosed_country_denies",
+			builder:  tcf2.ConsentBuilder{PublisherCC: "FR", PurposeOneTreatment: true, VendorConsents: []uint16{1}},
+			vendorID: 1,
+			expected: Decision{Reason: ReasonPurposeOneNotDisclosed},
+		},
+		{
+			name:     "not_disclosed_country_not_configured",
+			builder:  tcf2.ConsentBuilder{PublisherCC: "IT", PurposeOneTreatment: true, VendorConsents: []uint16{1}},
+			vendorID: 1,
+			expected: Decision{Reason: ReasonPurposeOneNotDisclosed},
+		},
+		{
+			name:     "not_disclosed_vendor_not_in_gvl",
+			builder:  tcf2.ConsentBuilder{PublisherCC: "DE", PurposeOneTreatment: true},
+			vendorID: 6,
+			expected: Decision{Reason: ReasonVendorNotInGVL},
+		},
+		{
+			name: "not_disclosed_publisher_restriction",
+			builder: tcf2.ConsentBuilder{
+				PublisherCC:         "DE",
+				PurposeOneTreatment: true,
+				PubRestrictions: []tcf2.PubRestriction{
+					{PurposeID: 1, RestrictType: tcf2.PubRestrictNotAllowed, Vendors: []tcf2.VendorRange{{StartID: 1, EndID: 1}}},
+				},
+			},
+			vendorID: 1,
+			expected: Decision{Reason: ReasonPubRestrictNotAllowed},
+		},
+	}
+
+	vendorList := parseVendorList(t, testVendorList)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consent := buildConsent(t, tt.builder)
+			assert.Equal(t, tt.expected, withoutTrace(enforcer.CheckDeviceStorage(consent, vendorList, tt.vendorID)))
+			assert.Equal(t, tt.expected, withoutTrace(enforcer.CheckPurpose(consent, vendorList, tt.vendorID, 1)))
+		})
+	}
+
+	// The zero value denies storage in every country where purpose 1 wasn't disclosed
+	consent := buildConsent(t, tcf2.ConsentBuilder{PublisherCC: "DE", PurposeOneTreatment: true})
+	assert.Equal(t, Decision{Reason: ReasonPurposeOneNotDisclosed}, withoutTrace(Enforcer{}.CheckDeviceStorage(consent, vendorList, 1)))
+}
+
+func TestCheckSpecialFeature(t *testing.T) {
+	tests := []struct {
+		name     string
+		optIns   []consentconstants.SpecialFeature
+		vendorID uint16
+		feature  consentconstants.SpecialFeature
+		expected Decision
+	}{
+		{
+			name:     "geolocation",
+			optIns:   []consentconstants.SpecialFeature{tcf2constants.Geolocation},
+			vendorID: 5,
+			feature:  tcf2constants.Geolocation,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonSpecialFeatureOptIn},
+		},
+		{
+			name:     "device_scan_without_opt_in",
+			optIns:   []consentconstants.SpecialFeature{tcf2constants.Geolocation},
+			vendorID: 5,
+			feature:  tcf2constants.DeviceScan,
+			expected: Decision{Reason: ReasonNoSpecialFeatureOptIn},
+		},
+		{
+			name:     "not_declared",
+			optIns:   []consentconstants.SpecialFeature{tcf2constants.Geolocation, tcf2constants.DeviceScan},
+			vendorID: 1,
+			feature:  tcf2constants.DeviceScan,
+			expected: Decision{Reason: ReasonSpecialFeatureNotDeclared},
+		},
+		{
+			name:     "vendor_not_in_gvl",
+			optIns:   []consentconstants.SpecialFeature{tcf2constants.Geolocation},
+			vendorID: 6,
+			feature:  tcf2constants.Geolocation,
+			expected: Decision{Reason: ReasonVendorNotInGVL},
+		},
+		{
+			name:     "invalid_special_feature",
+			vendorID: 5,
+			feature:  13,
+			expected: Decision{Reason: ReasonInvalidSpecialFeature},
+		},
+	}
+
+	vendorList := parseVendorList(t, testVendorList)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consent := buildConsent(t, tcf2.ConsentBuilder{SpecialFeatureOptIns: tt.optIns})
+			var enforcer Enforcer
+			assert.Equal(t, tt.expected, withoutTrace(enforcer.CheckSpecialFeature(consent, vendorList, tt.vendorID, tt.feature)))
+		})
+	}
+}
+
+func TestCheckVendorDisclosed(t *testing.T) {
+	tests := []struct {
+		name             string
+		policyVersion    uint8
+		disclosedVendors []uint16
+		vendorID         uint16
+		expected         Decision
+	}{
+		{
+			name:             "disclosed",
+			policyVersion:    4,
+			disclosedVendors: []uint16{1, 5},
+			vendorID:         5,
+			expected:         Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent},
+		},
+		{
+			name:             "not_disclosed",
+			policyVersion:    4,
+			disclosedVendors: []uint16{1},
+			vendorID:         5,
+			expected:         Decision{Reason: ReasonVendorNotDisclosed},
+		},
+		{
+			name:             "not_disclosed_later_policy_version",
+			policyVersion:    5,
+			disclosedVendors: []uint16{1},
+			vendorID:         5,
+			expected:         Decision{Reason: ReasonVendorNotDisclosed},
+		},
+		{
+			name:             "not_disclosed_before_policy_version_4",
+			policyVersion:    2,
+			disclosedVendors: []uint16{1},
+			vendorID:         5,
+			expected:         Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent},
+		},
+		{
+			name:          "no_disclosed_vendors_segment",
+			policyVersion: 4,
+			vendorID:      5,
+			expected:      Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent},
+		},
+	}
+
+	vendorList := parseVendorList(t, testVendorList)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consent := buildConsent(t, tcf2.ConsentBuilder{
+				TCFPolicyVersion:     tt.policyVersion,
+				PurposesConsent:      purposes(2),
+				VendorConsents:       []uint16{1, 5},
+				SpecialFeatureOptIns: []consentconstants.SpecialFeature{tcf2constants.Geolocation},
+				DisclosedVendors:     tt.disclosedVendors,
+			})
+			var enforcer Enforcer
+			assert.Equal(t, tt.expected, withoutTrace(enforcer.CheckPurpose(consent, vendorList, tt.vendorID, 2)))
+
+			// Special features rely on the user's opt-in, which undisclosed vendors can't have either
+			featureDecision := enforcer.CheckSpecialFeature(consent, vendorList, tt.vendorID, tcf2constants.Geolocation)
+			assert.Equal(t, tt.expected.Allowed, featureDecision.Allowed)
+			if !tt.expected.Allowed {
+				assert.Equal(t, ReasonVendorNotDisclosed, featureDecision.Reason)
+			}
+		})
+	}
+}
+
+func TestCheckVendorDisclosedWithPolicy(t *testing.T) {
+	consent := buildConsent(t, tcf2.ConsentBuilder{
+		TCFPolicyVersion:       4,
+		PurposesLITransparency: purposes(3),
+		VendorConsents:         []uint16{1},
+		DisclosedVendors:       []uint16{1},
+	})
+	enforcer := Enforcer{Policy: Policy{Purposes: map[consentconstants.Purpose]PurposePolicy{
+		3: {Mode: ModeBasic, VendorExceptions: []uint16{2}},
+		4: {Disabled: true},
+	}}}
+	vendorList := parseVendorList(t, testVendorList)
+
+	// Vendor exceptions and disabled purposes don't need a legal basis, while ModeBasic does
+	assert.Equal(t, ReasonVendorException, enforcer.CheckPurpose(consent, vendorList, 2, 3).Reason)
+	assert.Equal(t, ReasonPurposeNotEnforced, enforcer.CheckPurpose(consent, vendorList, 3, 4).Reason)
+	assert.Equal(t, ReasonVendorNotDisclosed, enforcer.CheckPurpose(consent, vendorList, 3, 3).Reason)
+}
+
+func TestCheckPurposeUnsupportedConsent(t *testing.T) {
+	consent, err := tcf1.ParseString("BONV8oqONXwgmADACHENAO7pqzAAppY")
+	require.NoError(t, err)
+
+	var enforcer Enforcer
+	decision := enforcer.CheckPurpose(consent, parseVendorList(t, testVendorList), 1, 1)
+	assert.Equal(t, Decision{Reason: ReasonUnsupportedConsent}, withoutTrace(decision))
+}
+
+func TestCheckPurposeNilVendorList(t *testing.T) {
+	consent := buildConsent(t, tcf2.ConsentBuilder{PurposesConsent: purposes(1), VendorConsents: []uint16{1}})
+
+	var enforcer Enforcer
+	assert.Equal(t, Decision{Reason: ReasonVendorNotInGVL}, withoutTrace(enforcer.CheckPurpose(consent, nil, 1, 1)))
+}
+
+func TestCheckPurposeAgreesWithVendorPurposeLegalBasis(t *testing.T) {
+	vendorList := parseVendorList(t, testVendorList)
+	for _, policyVersion := range []uint8{2, 4} {
+		consent := buildConsent(t, tcf2.ConsentBuilder{
+			TCFPolicyVersion:          policyVersion,
+			PurposesConsent:           purposes(1, 2, 3, 4),
+			PurposesLITransparency:    purposes(1, 2, 3, 7),
+			VendorConsents:            []uint16{1, 3, 4, 5},
+			VendorLegitimateInterests: []uint16{1, 2, 4},
+			PubRestrictions: []tcf2.PubRestriction{
+				{PurposeID: 2, RestrictType: tcf2.PubRestrictNotAllowed, Vendors: []tcf2.VendorRange{{StartID: 5, EndID: 5}}},
+				{PurposeID: 3, RestrictType: tcf2.PubRestrictRequireLI, Vendors: []tcf2.VendorRange{{StartID: 4, EndID: 4}}},
+			},
+		})
+		var enforcer Enforcer
+		for vendorID := uint16(1); vendorID <= 6; vendorID++ {
+			for purpose := consentconstants.Purpose(1); purpose <= 10; purpose++ {
+				decision := enforcer.CheckPurpose(consent, vendorList, vendorID, purpose)
+				expected := consentconstants.LegalBasisNone
+				if decision.Allowed {
+					expected = decision.LegalBasis
+				}
+				actual := consent.VendorPurposeLegalBasis(vendorID, vendorList.Vendor(vendorID), purpose)
+				assert.Equal(t, expected, actual, "policy version %d, vendor %d, purpose %d", policyVersion, vendorID, purpose)
+			}
+		}
+	}
+}
+
+// withoutTrace lets tests compare the outcome of a Decision, and leave its Trace to other tests
+func withoutTrace(decision Decision) Decision {
+	decision.Trace = nil
+	return decision
+}
+
+// buildConsent fills in the fields which every consent string needs, and parses the result
+func buildConsent(t *testing.T, builder tcf2.ConsentBuilder) tcf2.VendorConsents {
+	t.Helper()
+	if builder.ConsentLanguage == "" {
+		builder.ConsentLanguage = "EN"
+	}
+	if builder.PublisherCC == "" {
+		builder.PublisherCC = "FR"
+	}
+	if builder.VendorListVersion == 0 {
+		builder.VendorListVersion = 10
+	}
+	if builder.TCFPolicyVersion == 0 {
+		builder.TCFPolicyVersion = 2
+	}
+	encoded, err := builder.EncodeString()
+	require.NoError(t, err)
+	consent, err := tcf2.ParseString(encoded)
+	require.NoError(t, err)
+	return consent
+}
+
+func parseVendorList(t *testing.T, data string) api.VendorList {
+	t.Helper()
+	vendorList, err := vendorlist2.ParseEagerly([]byte(data))
+	require.NoError(t, err)
+	return vendorList
+}
+
+func purposes(ids ...consentconstants.Purpose) []consentconstants.Purpose {
+	return ids
+}
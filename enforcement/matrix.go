@@ -0,0 +1,172 @@
+package enforcement
+
+import (
+	"github.com/prebid/go-gdpr/api"
+	"github.com/prebid/go-gdpr/consentconstants"
+	tcf2 "github.com/prebid/go-gdpr/vendorconsent/tcf2"
+)
+
+// Matrix holds the Decision of every vendor for every purpose of a CheckMatrix call.
+// It is never modified once built, so it can be shared safely between goroutines.
+type Matrix struct {
+	vendorIDs []uint16
+	purposes  []consentconstants.Purpose
+	// rows maps each vendor ID to its row in decisions
+	rows map[uint16]int
+	// columns maps each purpose to its column in decisions
+	columns map[consentconstants.Purpose]int
+	// decisions holds one row of len(purposes) decisions for each vendor
+	decisions []Decision
+}
+
+// CheckMatrix runs CheckPurpose for every vendor and purpose, and returns the results in a Matrix.
+// This looks up each vendor in the vendor list once, and reads its consent, legitimate interest,
+// disclosure and publisher restrictions once, no matter how many purposes there are.
+//
+// Duplicate vendor IDs and purposes are ignored. The decisions in the Matrix have no Trace.
+// Use CheckPurpose to explain a single Decision.
+func (e Enforcer) CheckMatrix(consent api.VendorConsents, vendorList api.VendorList, vendorIDs []uint16, purposes []consentconstants.Purpose) *Matrix {
+	m := &Matrix{
+		rows:    make(map[uint16]int, len(vendorIDs)),
+		columns: make(map[consentconstants.Purpose]int, len(purposes)),
+	}
+	for _, purpose := range purposes {
+		if _, ok := m.columns[purpose]; ok {
+			continue
+		}
+		m.columns[purpose] = len(m.purposes)
+		m.purposes = append(m.purposes, purpose)
+	}
+	for _, vendorID := range vendorIDs {
+		if _, ok := m.rows[vendorID]; ok {
+			continue
+		}
+		m.rows[vendorID] = len(m.vendorIDs)
+		m.vendorIDs = append(m.vendorIDs, vendorID)
+	}
+
+	m.decisions = make([]Decision, len(m.vendorIDs)*len(m.purposes))
+	t := tracer{disabled: true}
+	for row, vendorID := range m.vendorIDs {
+		cachedConsent, cachedList := cacheVendor(consent, vendorList, vendorID)
+		for column, purpose := range m.purposes {
+			m.decisions[row*len(m.purposes)+column] = e.checkPurpose(&t, cachedConsent, cachedList, vendorID, purpose)
+		}
+	}
+	return m
+}
+
+// VendorIDs returns the vendors in the Matrix, in the order they were first passed to CheckMatrix.
+func (m *Matrix) VendorIDs() []uint16 {
+	return append([]uint16(nil), m.vendorIDs...)
+}
+
+// Purposes returns the purposes in the Matrix, in the order they were first passed to CheckMatrix.
+func (m *Matrix) Purposes() []consentconstants.Purpose {
+	return append([]consentconstants.Purpose(nil), m.purposes...)
+}
+
+// Decision returns the Decision of the vendor for the purpose.
+// The second return value is false if the vendor or the purpose isn't in the Matrix.
+func (m *Matrix) Decision(vendorID uint16, purpose consentconstants.Purpose) (Decision, bool) {
+	row, ok := m.rows[vendorID]
+	if !ok {
+		return Decision{}, false
+	}
+	column, ok := m.columns[purpose]
+	if !ok {
+		return Decision{}, false
+	}
+	return m.decisions[row*len(m.purposes)+column], true
+}
+
+// Allowed returns true if the vendor may process personal data for the purpose.
+// Vendors and purposes which aren't in the Matrix are never allowed.
+func (m *Matrix) Allowed(vendorID uint16, purpose consentconstants.Purpose) bool {
+	decision, _ := m.Decision(vendorID, purpose)
+	return decision.Allowed
+}
+
+// AllowedVendors returns the vendors which may process personal data for the purpose, in the order of VendorIDs.
+func (m *Matrix) AllowedVendors(purpose consentconstants.Purpose) []uint16 {
+	column, ok := m.columns[purpose]
+	if !ok {
+		return nil
+	}
+	var allowed []uint16
+	for row, vendorID := range m.vendorIDs {
+		if m.decisions[row*len(m.purposes)+column].Allowed {
+			allowed = append(allowed, vendorID)
+		}
+	}
+	return allowed
+}
+
+// cacheVendor wraps the consent string and the vendor list, so that the values which only depend on the vendor
+// are read once rather than once per purpose
+func cacheVendor(consent api.VendorConsents, vendorList api.VendorList, vendorID uint16) (api.VendorConsents, api.VendorList) {
+	if vendorList != nil {
+		vendorList = cachedVendorList{VendorList: vendorList, vendorID: vendorID, vendor: vendorList.Vendor(vendorID)}
+	}
+	if tcf2Consent, ok := consent.(tcf2.VendorConsents); ok {
+		consent = cachedVendorConsents{
+			VendorConsents:      tcf2Consent,
+			vendorID:            vendorID,
+			vendorConsent:       tcf2Consent.VendorConsent(vendorID),
+			vendorLegitInterest: tcf2Consent.VendorLegitInterest(vendorID),
+			vendorDisclosed:     tcf2Consent.VendorDisclosed(vendorID),
+			pubRestrictions:     tcf2Consent.VendorPubRestrictions(vendorID),
+		}
+	}
+	return consent, vendorList
+}
+
+type cachedVendorList struct {
+	api.VendorList
+	vendorID uint16
+	vendor   api.Vendor
+}
+
+func (l cachedVendorList) Vendor(vendorID uint16) api.Vendor {
+	if vendorID == l.vendorID {
+		return l.vendor
+	}
+	return l.VendorList.Vendor(vendorID)
+}
+
+type cachedVendorConsents struct {
+	tcf2.VendorConsents
+	vendorID            uint16
+	vendorConsent       bool
+	vendorLegitInterest bool
+	vendorDisclosed     bool
+	pubRestrictions     map[uint8]uint8
+}
+
+func (c cachedVendorConsents) VendorConsent(id uint16) bool {
+	if id == c.vendorID {
+		return c.vendorConsent
+	}
+	return c.VendorConsents.VendorConsent(id)
+}
+
+func (c cachedVendorConsents) VendorLegitInterest(id uint16) bool {
+	if id == c.vendorID {
+		return c.vendorLegitInterest
+	}
+	return c.VendorConsents.VendorLegitInterest(id)
+}
+
+func (c cachedVendorConsents) VendorDisclosed(id uint16) bool {
+	if id == c.vendorID {
+		return c.vendorDisclosed
+	}
+	return c.VendorConsents.VendorDisclosed(id)
+}
+
+func (c cachedVendorConsents) VendorPubRestrictions(vendor uint16) map[uint8]uint8 {
+	if vendor == c.vendorID {
+		return c.pubRestrictions
+	}
+	return c.VendorConsents.VendorPubRestrictions(vendor)
+}
@@ -0,0 +1,107 @@
+package enforcement
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/prebid/go-gdpr/api"
+	tcf2 "github.com/prebid/go-gdpr/vendorconsent/tcf2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMatrix(t *testing.T) {
+	builder := tcf2.ConsentBuilder{
+		PurposesConsent:           purposes(1, 2, 3),
+		PurposesLITransparency:    purposes(2, 3, 7),
+		VendorConsents:            []uint16{1, 3, 4},
+		VendorLegitimateInterests: []uint16{1, 2, 4},
+		PubRestrictions: []tcf2.PubRestriction{
+			{PurposeID: 3, RestrictType: tcf2.PubRestrictNotAllowed, Vendors: []tcf2.VendorRange{{StartID: 1, EndID: 1}}},
+			{PurposeID: 2, RestrictType: tcf2.PubRestrictRequireLI, Vendors: []tcf2.VendorRange{{StartID: 4, EndID: 4}}},
+		},
+	}
+	// TCF 2.2 consent strings deny the vendors which their Disclosed Vendors segment leaves out
+	disclosing := builder
+	disclosing.TCFPolicyVersion = 4
+	disclosing.DisclosedVendors = []uint16{1, 2, 4}
+	consents := map[string]api.VendorConsents{
+		"tcf2.0":            buildConsent(t, builder),
+		"disclosed_vendors": buildConsent(t, disclosing),
+	}
+	vendorList := parseVendorList(t, testVendorList)
+	vendorIDs := []uint16{4, 1, 2, 6, 1, 3}
+	checkedPurposes := purposes(1, 2, 3, 7, 2, 25)
+
+	var enforcer Enforcer
+	for name, consent := range consents {
+		t.Run(name, func(t *testing.T) {
+			matrix := enforcer.CheckMatrix(consent, vendorList, vendorIDs, checkedPurposes)
+			assert.Equal(t, []uint16{4, 1, 2, 6, 3}, matrix.VendorIDs())
+			assert.Equal(t, purposes(1, 2, 3, 7, 25), matrix.Purposes())
+
+			for _, vendorID := range matrix.VendorIDs() {
+				for _, purpose := range matrix.Purposes() {
+					decision, ok := matrix.Decision(vendorID, purpose)
+					assert.True(t, ok)
+					expected := withoutTrace(enforcer.CheckPurpose(consent, vendorList, vendorID, purpose))
+					assert.Equal(t, expected, decision, "vendor %d, purpose %d", vendorID, purpose)
+					assert.Equal(t, expected.Allowed, matrix.Allowed(vendorID, purpose))
+				}
+			}
+
+			assert.Equal(t, []uint16{4, 1, 2}, matrix.AllowedVendors(2))
+			assert.Equal(t, []uint16{1}, matrix.AllowedVendors(7))
+			assert.Nil(t, matrix.AllowedVendors(4))
+
+			_, ok := matrix.Decision(5, 1)
+			assert.False(t, ok)
+			_, ok = matrix.Decision(1, 4)
+			assert.False(t, ok)
+			assert.False(t, matrix.Allowed(1, 4))
+		})
+	}
+
+	// Vendor 3 has consent, but wasn't disclosed
+	matrix := enforcer.CheckMatrix(consents["disclosed_vendors"], vendorList, vendorIDs, checkedPurposes)
+	decision, _ := matrix.Decision(3, 1)
+	assert.Equal(t, ReasonVendorNotDisclosed, decision.Reason)
+	decision, _ = matrix.Decision(1, 1)
+	assert.True(t, decision.Allowed)
+}
+
+func TestCheckMatrixLooksUpVendorsOnce(t *testing.T) {
+	consent := buildConsent(t, tcf2.ConsentBuilder{PurposesConsent: purposes(1, 2, 3), VendorConsents: []uint16{1, 3}})
+	vendorList := &countingVendorList{VendorList: parseVendorList(t, testVendorList), lookups: make(map[uint16]int)}
+
+	var enforcer Enforcer
+	enforcer.CheckMatrix(consent, vendorList, []uint16{1, 2, 3}, purposes(1, 2, 3, 4, 5, 6, 7))
+	assert.Equal(t, map[uint16]int{1: 1, 2: 1, 3: 1}, vendorList.lookups)
+}
+
+func TestMatrixConcurrentReads(t *testing.T) {
+	consent := buildConsent(t, tcf2.ConsentBuilder{PurposesConsent: purposes(1, 2), VendorConsents: []uint16{1}})
+	var enforcer Enforcer
+	matrix := enforcer.CheckMatrix(consent, parseVendorList(t, testVendorList), []uint16{1, 2, 3}, purposes(1, 2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.True(t, matrix.Allowed(1, 2))
+			assert.Equal(t, []uint16{1}, matrix.AllowedVendors(1))
+		}()
+	}
+	wg.Wait()
+}
+
+// countingVendorList counts the lookups of each vendor
+type countingVendorList struct {
+	api.VendorList
+	lookups map[uint16]int
+}
+
+func (l *countingVendorList) Vendor(vendorID uint16) api.Vendor {
+	l.lookups[vendorID]++
+	return l.VendorList.Vendor(vendorID)
+}
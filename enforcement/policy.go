@@ -0,0 +1,122 @@
+package enforcement
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+// Policy configures how strictly an Enforcer checks each purpose. Purposes which aren't listed get the
+// full enforcement, so the zero value enforces every purpose fully.
+//
+// Policies are usually loaded from JSON with ParsePolicy:
+//
+//	{
+//		"purposes": {
+//			"1": {"mode": "basic"},
+//			"2": {"vendorExceptions": [32, 52]},
+//			"7": {"enabled": false}
+//		}
+//	}
+type Policy struct {
+	// Purposes maps purposes to their configuration.
+	Purposes map[consentconstants.Purpose]PurposePolicy `json:"purposes"`
+}
+
+// PurposePolicy configures the enforcement of one purpose.
+type PurposePolicy struct {
+	// Disabled is true if the purpose isn't enforced at all: every vendor is allowed to use it.
+	// The JSON holds the opposite, as "enabled", which defaults to true.
+	Disabled bool `json:"-"`
+	// Mode picks the checks which apply to the purpose. The empty value means ModeFull.
+	Mode EnforcementMode `json:"mode"`
+	// VendorExceptions lists the vendors which are allowed to use the purpose without any check.
+	VendorExceptions []uint16 `json:"vendorExceptions"`
+}
+
+// EnforcementMode picks the checks which apply to a purpose.
+type EnforcementMode string
+
+const (
+	// ModeFull applies every TCF 2 policy, including the declarations of the Global Vendor List.
+	ModeFull EnforcementMode = "full"
+	// ModeBasic ignores the Global Vendor List. The vendor needs either the consent of the user, or a legitimate
+	// interest which the user didn't object to, according to the consent string alone. Publisher restrictions
+	// which don't allow the purpose still apply.
+	ModeBasic EnforcementMode = "basic"
+)
+
+// ParsePolicy parses and validates a Policy from JSON.
+func ParsePolicy(data []byte) (Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return Policy{}, err
+	}
+	if err := policy.Validate(); err != nil {
+		return Policy{}, err
+	}
+	return policy, nil
+}
+
+// Validate returns an error if the Policy configures a purpose which doesn't exist, or uses an undefined mode.
+func (p Policy) Validate() error {
+	for purpose, purposePolicy := range p.Purposes {
+		if purpose < 1 || purpose > 24 {
+			return fmt.Errorf("policy for purpose %d is invalid: purposes must be in [1, 24]", purpose)
+		}
+		switch purposePolicy.Mode {
+		case "", ModeFull, ModeBasic:
+		default:
+			return fmt.Errorf("policy for purpose %d is invalid: mode %q is undefined", purpose, purposePolicy.Mode)
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON reads the "enabled" key into Disabled. The purpose stays enabled if the JSON leaves the key out.
+func (p *PurposePolicy) UnmarshalJSON(data []byte) error {
+	// purposePolicy has the same fields without the JSON methods, which would recurse
+	type purposePolicy PurposePolicy
+	var decoded struct {
+		purposePolicy
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*p = PurposePolicy(decoded.purposePolicy)
+	p.Disabled = decoded.Enabled != nil && !*decoded.Enabled
+	return nil
+}
+
+// MarshalJSON writes Disabled as the "enabled" key, so that ParsePolicy reads the output back.
+func (p PurposePolicy) MarshalJSON() ([]byte, error) {
+	type purposePolicy PurposePolicy
+	return json.Marshal(struct {
+		purposePolicy
+		Enabled bool `json:"enabled"`
+	}{purposePolicy(p), !p.Disabled})
+}
+
+// purpose returns the configuration of the purpose, with the defaults filled in,
+// and whether the Policy configures the purpose
+func (p Policy) purpose(purpose consentconstants.Purpose) (PurposePolicy, bool) {
+	purposePolicy, ok := p.Purposes[purpose]
+	if !ok {
+		return PurposePolicy{Mode: ModeFull}, false
+	}
+	if purposePolicy.Mode == "" {
+		purposePolicy.Mode = ModeFull
+	}
+	return purposePolicy, true
+}
+
+func (p PurposePolicy) isException(vendorID uint16) bool {
+	for _, exception := range p.VendorExceptions {
+		if exception == vendorID {
+			return true
+		}
+	}
+	return false
+}
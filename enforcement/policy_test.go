@@ -0,0 +1,221 @@
+package enforcement
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+	tcf2 "github.com/prebid/go-gdpr/vendorconsent/tcf2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`{
+		"purposes": {
+			"1": {"mode": "basic"},
+			"2": {"vendorExceptions": [3, 4]},
+			"7": {"enabled": false}
+		}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, Policy{Purposes: map[consentconstants.Purpose]PurposePolicy{
+		1: {Mode: ModeBasic},
+		2: {VendorExceptions: []uint16{3, 4}},
+		7: {Disabled: true},
+	}}, policy)
+}
+
+func TestPurposePolicyJSON(t *testing.T) {
+	policy := Policy{Purposes: map[consentconstants.Purpose]PurposePolicy{
+		1: {Mode: ModeBasic},
+		4: {Disabled: true, VendorExceptions: []uint16{2}},
+	}}
+	data, err := json.Marshal(policy)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"purposes": {
+		"1": {"enabled": true, "mode": "basic", "vendorExceptions": null},
+		"4": {"enabled": false, "mode": "", "vendorExceptions": [2]}
+	}}`, string(data))
+
+	parsed, err := ParsePolicy(data)
+	require.NoError(t, err)
+	assert.Equal(t, policy, parsed)
+}
+
+func TestPurposePolicyZeroValueEnforces(t *testing.T) {
+	// Literals which leave out Disabled must not let every vendor through
+	consent := buildConsent(t, tcf2.ConsentBuilder{PurposesConsent: purposes(2)})
+	enforcer := Enforcer{Policy: Policy{Purposes: map[consentconstants.Purpose]PurposePolicy{
+		2: {VendorExceptions: []uint16{3}},
+	}}}
+	decision := enforcer.CheckPurpose(consent, parseVendorList(t, testVendorList), 1, 2)
+	assert.False(t, decision.Allowed)
+	assert.NotEqual(t, ReasonPurposeNotEnforced, decision.Reason)
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "malformed", data: `{"purposes": [`},
+		{name: "purpose_0", data: `{"purposes": {"0": {}}}`},
+		{name: "purpose_25", data: `{"purposes": {"25": {}}}`},
+		{name: "undefined_mode", data: `{"purposes": {"1": {"mode": "strict"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCheckPurposeWithPolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`{
+		"purposes": {
+			"1": {"mode": "basic"},
+			"2": {"mode": "full", "vendorExceptions": [3]},
+			"3": {"mode": "basic", "vendorExceptions": [6]},
+			"4": {"enabled": false},
+			"7": {"mode": "basic"}
+		}
+	}`))
+	require.NoError(t, err)
+	enforcer := Enforcer{Policy: policy}
+
+	tests := []struct {
+		name     string
+		builder  tcf2.ConsentBuilder
+		vendorID uint16
+		purpose  consentconstants.Purpose
+		expected Decision
+	}{
+		{
+			name:     "disabled",
+			vendorID: 6,
+			purpose:  4,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisNone, Reason: ReasonPurposeNotEnforced},
+		},
+		{
+			name:     "vendor_exception",
+			vendorID: 3,
+			purpose:  2,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisNone, Reason: ReasonVendorException},
+		},
+		{
+			name: "vendor_exception_ignores_publisher_restrictions",
+			builder: tcf2.ConsentBuilder{
+				PubRestrictions: []tcf2.PubRestriction{
+					{PurposeID: 3, RestrictType: tcf2.PubRestrictNotAllowed, Vendors: []tcf2.VendorRange{{StartID: 6, EndID: 6}}},
+				},
+			},
+			vendorID: 6,
+			purpose:  3,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisNone, Reason: ReasonVendorException},
+		},
+		{
+			name:     "full_not_an_exception",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(2), VendorConsents: []uint16{1}},
+			vendorID: 1,
+			purpose:  2,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent},
+		},
+		{
+			name:     "full_follows_gvl_declaration",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(2), VendorConsents: []uint16{2}},
+			vendorID: 2,
+			purpose:  2,
+			expected: Decision{Reason: ReasonNoPurposeLegitimateInterest},
+		},
+		{
+			name:     "basic_vendor_not_in_gvl",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(3), VendorConsents: []uint16{7}},
+			vendorID: 7,
+			purpose:  3,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent},
+		},
+		{
+			name:     "basic_purpose_not_declared",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(7), VendorConsents: []uint16{3}},
+			vendorID: 3,
+			purpose:  7,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent},
+		},
+		{
+			name:     "basic_legitimate_interest",
+			builder:  tcf2.ConsentBuilder{PurposesLITransparency: purposes(3), VendorLegitimateInterests: []uint16{1}},
+			vendorID: 1,
+			purpose:  3,
+			expected: Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisLegitimateInterest, Reason: ReasonLegitimateInterest},
+		},
+		{
+			name:     "basic_mixed_legal_bases",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(3), VendorLegitimateInterests: []uint16{1}},
+			vendorID: 1,
+			purpose:  3,
+			expected: Decision{Reason: ReasonNoLegalBasis},
+		},
+		{
+			name:     "basic_purpose_1_legitimate_interest",
+			builder:  tcf2.ConsentBuilder{PurposesLITransparency: purposes(1), VendorLegitimateInterests: []uint16{2}},
+			vendorID: 2,
+			purpose:  1,
+			expected: Decision{Reason: ReasonNoLegalBasis},
+		},
+		{
+			name:     "basic_purpose_one_treatment",
+			builder:  tcf2.ConsentBuilder{PurposeOneTreatment: true, PurposesConsent: purposes(1), VendorConsents: []uint16{1}},
+			vendorID: 1,
+			purpose:  1,
+			expected: Decision{Reason: ReasonPurposeOneNotDisclosed},
+		},
+		{
+			name: "basic_publisher_restriction_not_allowed",
+			builder: tcf2.ConsentBuilder{
+				PurposesConsent: purposes(3),
+				VendorConsents:  []uint16{1},
+				PubRestrictions: []tcf2.PubRestriction{
+					{PurposeID: 3, RestrictType: tcf2.PubRestrictNotAllowed, Vendors: []tcf2.VendorRange{{StartID: 1, EndID: 1}}},
+				},
+			},
+			vendorID: 1,
+			purpose:  3,
+			expected: Decision{Reason: ReasonPubRestrictNotAllowed},
+		},
+		{
+			name:     "not_configured",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(5), VendorConsents: []uint16{3}},
+			vendorID: 3,
+			purpose:  5,
+			expected: Decision{Reason: ReasonPurposeNotDeclared},
+		},
+	}
+
+	vendorList := parseVendorList(t, testVendorList)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consent := buildConsent(t, tt.builder)
+			assert.Equal(t, tt.expected, withoutTrace(enforcer.CheckPurpose(consent, vendorList, tt.vendorID, tt.purpose)))
+		})
+	}
+}
+
+func TestPolicyTrace(t *testing.T) {
+	enforcer := Enforcer{Policy: Policy{Purposes: map[consentconstants.Purpose]PurposePolicy{
+		3: {Mode: ModeBasic},
+	}}}
+	consent := buildConsent(t, tcf2.ConsentBuilder{PurposesLITransparency: purposes(3), VendorLegitimateInterests: []uint16{7}})
+	decision := enforcer.CheckPurpose(consent, parseVendorList(t, testVendorList), 7, 3)
+	assert.Equal(t, []Step{
+		{Input: InputConsentVersion, Value: uint8(2), Effect: EffectSatisfied},
+		{Input: InputPolicyEnabled, Value: true, Effect: EffectInformational},
+		{Input: InputPolicyVendorException, Value: false, Effect: EffectInformational},
+		{Input: InputPolicyMode, Value: ModeBasic, Effect: EffectInformational},
+		{Input: InputPubRestriction, Value: nil, Effect: EffectInformational},
+		{Input: InputPurposeConsent, Value: false, Effect: EffectDenied},
+		{Input: InputPurposeLITransparency, Value: true, Effect: EffectSatisfied},
+		{Input: InputVendorLegitInterest, Value: true, Effect: EffectSatisfied},
+	}, decision.Trace)
+}
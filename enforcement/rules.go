@@ -0,0 +1,62 @@
+package enforcement
+
+import "github.com/prebid/go-gdpr/consentconstants"
+
+// RuleSet holds the TCF policies which change between policy versions.
+// Implement it, along with a RuleSetSelector, to enforce policy versions which this package doesn't know yet.
+type RuleSet interface {
+	// Name identifies the RuleSet in the Trace of a Decision.
+	Name() string
+	// LegitimateInterestAllowed returns false if no vendor may rely on legitimate interest for the purpose.
+	LegitimateInterestAllowed(purpose consentconstants.Purpose) bool
+}
+
+// RuleSetSelector picks the RuleSet for a consent string of the TCF policy version, checked against
+// a Global Vendor List of the specification version. The gvlSpecVersion is 0 when the check doesn't
+// use the vendor list, such as under ModeBasic.
+type RuleSetSelector func(tcfPolicyVersion uint8, gvlSpecVersion uint16) RuleSet
+
+var (
+	// TCF20Rules are the policies of TCF 2.0 and 2.1, which only forbid legitimate interest for purpose 1.
+	TCF20Rules RuleSet = legitimateInterestRules{name: "tcf2.0", forbidden: []consentconstants.Purpose{1}}
+	// TCF22Rules are the policies of TCF 2.2, which also forbid legitimate interest for the personalization
+	// purposes 3, 4, 5 and 6.
+	TCF22Rules RuleSet = legitimateInterestRules{name: "tcf2.2", forbidden: []consentconstants.Purpose{1, 3, 4, 5, 6}}
+)
+
+// SelectRuleSet is the default RuleSetSelector. It picks TCF22Rules for consent strings of TCF policy version 4
+// or later, and for vendor lists of specification version 3 or later, since both were made for TCF 2.2.
+// Anything older gets TCF20Rules.
+func SelectRuleSet(tcfPolicyVersion uint8, gvlSpecVersion uint16) RuleSet {
+	if tcfPolicyVersion >= 4 || gvlSpecVersion >= 3 {
+		return TCF22Rules
+	}
+	return TCF20Rules
+}
+
+// legitimateInterestRules implements RuleSet with the list of purposes which forbid legitimate interest
+type legitimateInterestRules struct {
+	name      string
+	forbidden []consentconstants.Purpose
+}
+
+func (r legitimateInterestRules) Name() string {
+	return r.name
+}
+
+func (r legitimateInterestRules) LegitimateInterestAllowed(purpose consentconstants.Purpose) bool {
+	for _, forbidden := range r.forbidden {
+		if forbidden == purpose {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleSet returns the RuleSet which applies to the versions
+func (e Enforcer) ruleSet(tcfPolicyVersion uint8, gvlSpecVersion uint16) RuleSet {
+	if e.RuleSets == nil {
+		return SelectRuleSet(tcfPolicyVersion, gvlSpecVersion)
+	}
+	return e.RuleSets(tcfPolicyVersion, gvlSpecVersion)
+}
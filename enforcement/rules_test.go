@@ -0,0 +1,182 @@
+package enforcement
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+	tcf2 "github.com/prebid/go-gdpr/vendorconsent/tcf2"
+	"github.com/stretchr/testify/assert"
+)
+
+// rulesVendorList declares, in a vendor list of the specification version:
+//
+//	vendor 1: legitimate interest for purposes 3 and 7
+//	vendor 2: flexible purpose 4 (legitimate interest by default)
+const rulesVendorList = `{
+	"gvlSpecificationVersion": %d,
+	"vendorListVersion": 10,
+	"vendors": {
+		"1": {"id": 1, "purposes": [], "legIntPurposes": [3, 7], "flexiblePurposes": [], "specialPurposes": [], "specialFeatures": []},
+		"2": {"id": 2, "purposes": [], "legIntPurposes": [4], "flexiblePurposes": [4], "specialPurposes": [], "specialFeatures": []}
+	}
+}`
+
+func TestSelectRuleSet(t *testing.T) {
+	assert.Equal(t, TCF20Rules, SelectRuleSet(2, 2))
+	assert.Equal(t, TCF20Rules, SelectRuleSet(3, 0))
+	assert.Equal(t, TCF22Rules, SelectRuleSet(4, 2))
+	assert.Equal(t, TCF22Rules, SelectRuleSet(2, 3))
+	assert.Equal(t, TCF22Rules, SelectRuleSet(5, 3))
+}
+
+func TestRuleSets(t *testing.T) {
+	for purpose := consentconstants.Purpose(1); purpose <= 10; purpose++ {
+		assert.Equal(t, purpose != 1, TCF20Rules.LegitimateInterestAllowed(purpose), "TCF 2.0, purpose %d", purpose)
+		assert.Equal(t, purpose == 2 || purpose > 6, TCF22Rules.LegitimateInterestAllowed(purpose), "TCF 2.2, purpose %d", purpose)
+	}
+}
+
+func TestCheckPurposeRuleSets(t *testing.T) {
+	legitimateInterests := tcf2.ConsentBuilder{
+		PurposesConsent:           purposes(3, 4, 7),
+		PurposesLITransparency:    purposes(3, 4, 7),
+		VendorConsents:            []uint16{1, 2},
+		VendorLegitimateInterests: []uint16{1},
+	}
+	requireLI := legitimateInterests
+	requireLI.PubRestrictions = []tcf2.PubRestriction{
+		{PurposeID: 4, RestrictType: tcf2.PubRestrictRequireLI, Vendors: []tcf2.VendorRange{{StartID: 2, EndID: 2}}},
+	}
+
+	tests := []struct {
+		name           string
+		builder        tcf2.ConsentBuilder
+		policyVersion  uint8
+		gvlSpecVersion uint16
+		vendorID       uint16
+		purpose        consentconstants.Purpose
+		expected       Decision
+	}{
+		{
+			name:           "tcf20_legitimate_interest",
+			builder:        legitimateInterests,
+			policyVersion:  2,
+			gvlSpecVersion: 2,
+			vendorID:       1,
+			purpose:        3,
+			expected:       Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisLegitimateInterest, Reason: ReasonLegitimateInterest},
+		},
+		{
+			name:           "tcf22_policy_version",
+			builder:        legitimateInterests,
+			policyVersion:  4,
+			gvlSpecVersion: 2,
+			vendorID:       1,
+			purpose:        3,
+			expected:       Decision{Reason: ReasonLegitimateInterestNotAllowed},
+		},
+		{
+			name:           "tcf22_gvl_spec_version",
+			builder:        legitimateInterests,
+			policyVersion:  2,
+			gvlSpecVersion: 3,
+			vendorID:       1,
+			purpose:        3,
+			expected:       Decision{Reason: ReasonLegitimateInterestNotAllowed},
+		},
+		{
+			name:           "tcf22_other_purpose",
+			builder:        legitimateInterests,
+			policyVersion:  4,
+			gvlSpecVersion: 3,
+			vendorID:       1,
+			purpose:        7,
+			expected:       Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisLegitimateInterest, Reason: ReasonLegitimateInterest},
+		},
+		{
+			name:           "tcf20_flexible_legitimate_interest",
+			builder:        legitimateInterests,
+			policyVersion:  2,
+			gvlSpecVersion: 2,
+			vendorID:       2,
+			purpose:        4,
+			expected:       Decision{Reason: ReasonNoVendorLegitimateInterest},
+		},
+		{
+			name:           "tcf22_flexible_falls_back_to_consent",
+			builder:        legitimateInterests,
+			policyVersion:  4,
+			gvlSpecVersion: 2,
+			vendorID:       2,
+			purpose:        4,
+			expected:       Decision{Allowed: true, LegalBasis: consentconstants.LegalBasisConsent, Reason: ReasonConsent},
+		},
+		{
+			name:           "tcf22_flexible_publisher_requires_legitimate_interest",
+			builder:        requireLI,
+			policyVersion:  4,
+			gvlSpecVersion: 2,
+			vendorID:       2,
+			purpose:        4,
+			expected:       Decision{Reason: ReasonLegitimateInterestNotAllowed},
+		},
+	}
+
+	var enforcer Enforcer
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := tt.builder
+			builder.TCFPolicyVersion = tt.policyVersion
+			consent := buildConsent(t, builder)
+			vendorList := parseVendorList(t, fmt.Sprintf(rulesVendorList, tt.gvlSpecVersion))
+			assert.Equal(t, tt.expected, withoutTrace(enforcer.CheckPurpose(consent, vendorList, tt.vendorID, tt.purpose)))
+		})
+	}
+}
+
+func TestCheckPurposeBasicRuleSets(t *testing.T) {
+	enforcer := Enforcer{Policy: Policy{Purposes: map[consentconstants.Purpose]PurposePolicy{
+		3: {Mode: ModeBasic},
+	}}}
+	builder := tcf2.ConsentBuilder{PurposesLITransparency: purposes(3), VendorLegitimateInterests: []uint16{1}}
+
+	builder.TCFPolicyVersion = 2
+	decision := enforcer.CheckPurpose(buildConsent(t, builder), nil, 1, 3)
+	assert.Equal(t, ReasonLegitimateInterest, decision.Reason)
+
+	builder.TCFPolicyVersion = 4
+	decision = enforcer.CheckPurpose(buildConsent(t, builder), nil, 1, 3)
+	assert.Equal(t, ReasonNoLegalBasis, decision.Reason)
+	assert.Equal(t, Step{Input: InputRuleSet, Value: "tcf2.2", Effect: EffectDenied}, decision.Trace[len(decision.Trace)-1])
+}
+
+// noPurposeSevenRules forbids legitimate interest for purpose 7, on top of the TCF 2.2 rules
+type noPurposeSevenRules struct{}
+
+func (noPurposeSevenRules) Name() string {
+	return "no_purpose_7"
+}
+
+func (noPurposeSevenRules) LegitimateInterestAllowed(purpose consentconstants.Purpose) bool {
+	return purpose != 7 && TCF22Rules.LegitimateInterestAllowed(purpose)
+}
+
+func TestCustomRuleSets(t *testing.T) {
+	enforcer := Enforcer{RuleSets: func(tcfPolicyVersion uint8, gvlSpecVersion uint16) RuleSet {
+		if tcfPolicyVersion >= 5 {
+			return noPurposeSevenRules{}
+		}
+		return SelectRuleSet(tcfPolicyVersion, gvlSpecVersion)
+	}}
+	vendorList := parseVendorList(t, fmt.Sprintf(rulesVendorList, 3))
+	builder := tcf2.ConsentBuilder{PurposesLITransparency: purposes(7), VendorLegitimateInterests: []uint16{1}}
+
+	builder.TCFPolicyVersion = 4
+	assert.Equal(t, ReasonLegitimateInterest, enforcer.CheckPurpose(buildConsent(t, builder), vendorList, 1, 7).Reason)
+
+	builder.TCFPolicyVersion = 5
+	decision := enforcer.CheckPurpose(buildConsent(t, builder), vendorList, 1, 7)
+	assert.Equal(t, ReasonLegitimateInterestNotAllowed, decision.Reason)
+	assert.Equal(t, Step{Input: InputRuleSet, Value: "no_purpose_7", Effect: EffectDenied}, decision.Trace[len(decision.Trace)-1])
+}
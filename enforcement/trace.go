@@ -0,0 +1,156 @@
+package enforcement
+
+import (
+	"github.com/prebid/go-gdpr/api"
+	tcf2 "github.com/prebid/go-gdpr/vendorconsent/tcf2"
+)
+
+// Step is one input which an enforcement check consulted, and how it affected the Decision.
+type Step struct {
+	// Input names the value which was consulted.
+	Input Input `json:"input"`
+	// Value is the value of the input. Its type depends on the Input: see the Input constants.
+	// It is nil if the input wasn't set, such as a publisher restriction which doesn't exist.
+	Value interface{} `json:"value"`
+	// Effect says how the value affected the Decision.
+	Effect Effect `json:"effect"`
+}
+
+// Input names a value which an enforcement check consulted.
+type Input string
+
+const (
+	// InputConsentVersion is the version of the consent string, as a uint8.
+	InputConsentVersion Input = "consent_version"
+	// InputPurpose is the purpose which was checked, as a consentconstants.Purpose.
+	InputPurpose Input = "purpose"
+	// InputSpecialFeature is the special feature which was checked, as a consentconstants.SpecialFeature.
+	InputSpecialFeature Input = "special_feature"
+	// InputPolicyEnabled is true if the Policy enforces the purpose.
+	InputPolicyEnabled Input = "policy_enabled"
+	// InputPolicyVendorException is true if the Policy exempts the vendor from the enforcement of the purpose.
+	InputPolicyVendorException Input = "policy_vendor_exception"
+	// InputPolicyMode is the EnforcementMode of the purpose in the Policy.
+	InputPolicyMode Input = "policy_mode"
+	// InputTCFPolicyVersion is the TCF policy version of the consent string, as a uint8. It is only recorded
+	// when the consent string includes the Disclosed Vendors segment.
+	InputTCFPolicyVersion Input = "tcf_policy_version"
+	// InputVendorDisclosed is the bit of the Disclosed Vendors segment for the vendor.
+	InputVendorDisclosed Input = "vendor_disclosed"
+	// InputGVLVendor is true if the vendor is in the Global Vendor List.
+	InputGVLVendor Input = "gvl_vendor"
+	// InputGVLDeclaration is the legal basis which the vendor declared for the purpose in the Global Vendor List,
+	// as a consentconstants.LegalBasis. This is the default legal basis of flexible purposes.
+	InputGVLDeclaration Input = "gvl_declaration"
+	// InputGVLFlexible is true if the vendor declared the purpose as flexible in the Global Vendor List.
+	InputGVLFlexible Input = "gvl_flexible"
+	// InputGVLSpecialFeature is true if the vendor declared the special feature in the Global Vendor List.
+	InputGVLSpecialFeature Input = "gvl_special_feature"
+	// InputPubRestriction is the type of the publisher restriction on the purpose for the vendor, as a uint8,
+	// or nil if the publisher didn't restrict it.
+	InputPubRestriction Input = "publisher_restriction"
+	// InputEffectiveLegalBasis is the legal basis which the vendor must rely on, once the publisher restrictions
+	// and the RuleSet apply, as a consentconstants.LegalBasis.
+	InputEffectiveLegalBasis Input = "effective_legal_basis"
+	// InputRuleSet is the Name of the RuleSet, as a string. It is only recorded when the RuleSet forbids
+	// legitimate interest for the purpose.
+	InputRuleSet Input = "rule_set"
+	// InputPurposeConsent is the bit of PurposesConsent for the purpose.
+	InputPurposeConsent Input = "purpose_consent"
+	// InputVendorConsent is the bit of the vendor consents for the vendor.
+	InputVendorConsent Input = "vendor_consent"
+	// InputPurposeLITransparency is the bit of PurposesLITransparency for the purpose.
+	InputPurposeLITransparency Input = "purpose_li_transparency"
+	// InputVendorLegitInterest is the bit of the vendor legitimate interests for the vendor.
+	InputVendorLegitInterest Input = "vendor_legitimate_interest"
+	// InputPurposeOneTreatment is the PurposeOneTreatment bit.
+	InputPurposeOneTreatment Input = "purpose_one_treatment"
+	// InputPublisherCC is the two-letter country code of the publisher, as a string.
+	InputPublisherCC Input = "publisher_cc"
+	// InputPurposeOnePolicy is the PurposeOnePolicy of the publisher's country, as a string.
+	InputPurposeOnePolicy Input = "purpose_one_policy"
+	// InputSpecialFeatureOptIn is the bit of SpecialFeatureOptIns for the special feature.
+	InputSpecialFeatureOptIn Input = "special_feature_opt_in"
+)
+
+// Effect says how an input affected a Decision.
+type Effect string
+
+const (
+	// EffectSatisfied means that the input met a requirement of the Decision.
+	EffectSatisfied Effect = "satisfied"
+	// EffectDenied means that the input failed a requirement, which denied the processing.
+	EffectDenied Effect = "denied"
+	// EffectInformational means that the input picked which requirements apply, without allowing or denying anything.
+	EffectInformational Effect = "informational"
+)
+
+// tracer records the steps of an enforcement check, and builds the Decision
+type tracer struct {
+	steps []Step
+	// disabled skips the recording, for callers which don't return the trace
+	disabled bool
+}
+
+func (t *tracer) record(input Input, value interface{}, effect Effect) {
+	if !t.disabled {
+		t.steps = append(t.steps, Step{Input: input, Value: value, Effect: effect})
+	}
+}
+
+// require records a boolean input which must be true for the processing to be allowed, and returns its value
+func (t *tracer) require(input Input, value bool) bool {
+	if value {
+		t.record(input, value, EffectSatisfied)
+	} else {
+		t.record(input, value, EffectDenied)
+	}
+	return value
+}
+
+// tcf2Consent records the version of the consent string, which must be a TCF 2 consent string
+func (t *tracer) tcf2Consent(consent api.VendorConsents) (tcf2.VendorConsents, bool) {
+	if consent == nil {
+		t.record(InputConsentVersion, nil, EffectDenied)
+		return nil, false
+	}
+	tcf2Consent, ok := consent.(tcf2.VendorConsents)
+	if !ok {
+		t.record(InputConsentVersion, consent.Version(), EffectDenied)
+		return nil, false
+	}
+	t.record(InputConsentVersion, consent.Version(), EffectSatisfied)
+	return tcf2Consent, true
+}
+
+// vendor records whether the vendor is in the Global Vendor List, and returns it
+func (t *tracer) vendor(vendorList api.VendorList, vendorID uint16) api.Vendor {
+	var vendor api.Vendor
+	if vendorList != nil {
+		vendor = vendorList.Vendor(vendorID)
+	}
+	t.require(InputGVLVendor, vendor != nil)
+	return vendor
+}
+
+// disclosedVendorsPolicyVersion is the first TCF policy version (TCF 2.2) under which vendors which
+// weren't disclosed to the user have no legal basis
+const disclosedVendorsPolicyVersion = 4
+
+// disclosed records whether the vendor was disclosed to the user, if the consent string can tell,
+// and returns false if it wasn't
+func (t *tracer) disclosed(consent tcf2.VendorConsents, vendorID uint16) bool {
+	if !consent.HasDisclosedVendorsSegment() {
+		return true
+	}
+	policyVersion := consent.TCFPolicyVersion()
+	t.record(InputTCFPolicyVersion, policyVersion, EffectInformational)
+	if policyVersion < disclosedVendorsPolicyVersion {
+		return true
+	}
+	return t.require(InputVendorDisclosed, consent.VendorDisclosed(vendorID))
+}
+
+func (t *tracer) deny(reason Reason) Decision {
+	return Decision{Reason: reason, Trace: t.steps}
+}
@@ -0,0 +1,141 @@
+package enforcement
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+	tcf2 "github.com/prebid/go-gdpr/vendorconsent/tcf2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  tcf2.ConsentBuilder
+		vendorID uint16
+		purpose  consentconstants.Purpose
+		expected []Step
+	}{
+		{
+			name:     "consent",
+			builder:  tcf2.ConsentBuilder{PurposesConsent: purposes(2), VendorConsents: []uint16{1}},
+			vendorID: 1,
+			purpose:  2,
+			expected: []Step{
+				{Input: InputConsentVersion, Value: uint8(2), Effect: EffectSatisfied},
+				{Input: InputGVLVendor, Value: true, Effect: EffectSatisfied},
+				{Input: InputPubRestriction, Value: nil, Effect: EffectInformational},
+				{Input: InputGVLDeclaration, Value: consentconstants.LegalBasisConsent, Effect: EffectInformational},
+				{Input: InputGVLFlexible, Value: false, Effect: EffectInformational},
+				{Input: InputEffectiveLegalBasis, Value: consentconstants.LegalBasisConsent, Effect: EffectInformational},
+				{Input: InputPurposeConsent, Value: true, Effect: EffectSatisfied},
+				{Input: InputVendorConsent, Value: true, Effect: EffectSatisfied},
+			},
+		},
+		{
+			name: "flexible_purpose_without_vendor_legitimate_interest",
+			builder: tcf2.ConsentBuilder{
+				PurposesLITransparency: purposes(2),
+				VendorConsents:         []uint16{4},
+				PubRestrictions: []tcf2.PubRestriction{
+					{PurposeID: 2, RestrictType: tcf2.PubRestrictRequireLI, Vendors: []tcf2.VendorRange{{StartID: 4, EndID: 4}}},
+				},
+			},
+			vendorID: 4,
+			purpose:  2,
+			expected: []Step{
+				{Input: InputConsentVersion, Value: uint8(2), Effect: EffectSatisfied},
+				{Input: InputGVLVendor, Value: true, Effect: EffectSatisfied},
+				{Input: InputPubRestriction, Value: tcf2.PubRestrictRequireLI, Effect: EffectInformational},
+				{Input: InputGVLDeclaration, Value: consentconstants.LegalBasisConsent, Effect: EffectInformational},
+				{Input: InputGVLFlexible, Value: true, Effect: EffectInformational},
+				{Input: InputEffectiveLegalBasis, Value: consentconstants.LegalBasisLegitimateInterest, Effect: EffectInformational},
+				{Input: InputPurposeLITransparency, Value: true, Effect: EffectSatisfied},
+				{Input: InputVendorLegitInterest, Value: false, Effect: EffectDenied},
+			},
+		},
+		{
+			name:     "vendor_not_in_gvl",
+			builder:  tcf2.ConsentBuilder{},
+			vendorID: 6,
+			purpose:  2,
+			expected: []Step{
+				{Input: InputConsentVersion, Value: uint8(2), Effect: EffectSatisfied},
+				{Input: InputGVLVendor, Value: false, Effect: EffectDenied},
+			},
+		},
+		{
+			name:     "vendor_not_disclosed",
+			builder:  tcf2.ConsentBuilder{TCFPolicyVersion: 4, PurposesConsent: purposes(2), VendorConsents: []uint16{1}, DisclosedVendors: []uint16{2}},
+			vendorID: 1,
+			purpose:  2,
+			expected: []Step{
+				{Input: InputConsentVersion, Value: uint8(2), Effect: EffectSatisfied},
+				{Input: InputTCFPolicyVersion, Value: uint8(4), Effect: EffectInformational},
+				{Input: InputVendorDisclosed, Value: false, Effect: EffectDenied},
+			},
+		},
+		{
+			name:     "purpose_one_treatment",
+			builder:  tcf2.ConsentBuilder{PublisherCC: "DE", PurposeOneTreatment: true},
+			vendorID: 1,
+			purpose:  1,
+			expected: []Step{
+				{Input: InputConsentVersion, Value: uint8(2), Effect: EffectSatisfied},
+				{Input: InputGVLVendor, Value: true, Effect: EffectSatisfied},
+				{Input: InputPubRestriction, Value: nil, Effect: EffectInformational},
+				{Input: InputPurposeOneTreatment, Value: true, Effect: EffectInformational},
+				{Input: InputPublisherCC, Value: "DE", Effect: EffectInformational},
+				{Input: InputPurposeOnePolicy, Value: "deny", Effect: EffectDenied},
+			},
+		},
+	}
+
+	vendorList := parseVendorList(t, testVendorList)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consent := buildConsent(t, tt.builder)
+			var enforcer Enforcer
+			assert.Equal(t, tt.expected, enforcer.CheckPurpose(consent, vendorList, tt.vendorID, tt.purpose).Trace)
+		})
+	}
+}
+
+func TestTraceSpecialFeature(t *testing.T) {
+	consent := buildConsent(t, tcf2.ConsentBuilder{})
+	var enforcer Enforcer
+	decision := enforcer.CheckSpecialFeature(consent, parseVendorList(t, testVendorList), 5, 1)
+	expected := []Step{
+		{Input: InputConsentVersion, Value: uint8(2), Effect: EffectSatisfied},
+		{Input: InputGVLVendor, Value: true, Effect: EffectSatisfied},
+		{Input: InputGVLSpecialFeature, Value: true, Effect: EffectSatisfied},
+		{Input: InputSpecialFeatureOptIn, Value: false, Effect: EffectDenied},
+	}
+	assert.Equal(t, expected, decision.Trace)
+}
+
+func TestDecisionJSON(t *testing.T) {
+	consent := buildConsent(t, tcf2.ConsentBuilder{PurposesLITransparency: purposes(7), VendorLegitimateInterests: []uint16{1}})
+	var enforcer Enforcer
+	decision := enforcer.CheckPurpose(consent, parseVendorList(t, testVendorList), 1, 7)
+
+	data, err := json.Marshal(decision)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"allowed": true,
+		"legalBasis": "legitimate_interest",
+		"reason": "legitimate_interest",
+		"trace": [
+			{"input": "consent_version", "value": 2, "effect": "satisfied"},
+			{"input": "gvl_vendor", "value": true, "effect": "satisfied"},
+			{"input": "publisher_restriction", "value": null, "effect": "informational"},
+			{"input": "gvl_declaration", "value": "legitimate_interest", "effect": "informational"},
+			{"input": "gvl_flexible", "value": false, "effect": "informational"},
+			{"input": "effective_legal_basis", "value": "legitimate_interest", "effect": "informational"},
+			{"input": "purpose_li_transparency", "value": true, "effect": "satisfied"},
+			{"input": "vendor_legitimate_interest", "value": true, "effect": "satisfied"}
+		]
+	}`, string(data))
+}
@@ -1,6 +1,8 @@
 package vendorconsent
 
 import (
+	"fmt"
+
 	"github.com/prebid/go-gdpr/api"
 	"github.com/prebid/go-gdpr/consentconstants"
 	tcf1 "github.com/prebid/go-gdpr/vendorconsent/tcf1"
@@ -20,6 +22,41 @@ func ParseString(consent string) (api.VendorConsents, error) {
 	return tcf1.ParseString(consent)
 }
 
+// EncodingMode picks how Encode writes a consent string.
+type EncodingMode uint8
+
+const (
+	// Lossless writes back the exact bytes the consent string was parsed from.
+	Lossless EncodingMode = iota
+	// Canonical encodes the values of the consent string from scratch, so that consent strings which hold
+	// the same values always produce the same output. Vendors use their shortest encoding, trailing bits
+	// and unknown TCF 2.0 segments are dropped, and the known segments are sorted.
+	Canonical
+)
+
+// Encode writes a consent string returned by ParseString back into its base64 form.
+// Canonical also accepts consent strings which weren't returned by ParseString.
+func Encode(consent api.VendorConsents, mode EncodingMode) (string, error) {
+	if mode != Lossless && mode != Canonical {
+		return "", fmt.Errorf("EncodingMode %d is undefined", mode)
+	}
+
+	if v2, ok := consent.(tcf2.VendorConsents); ok {
+		if mode == Lossless {
+			return tcf2.EncodeLossless(v2)
+		}
+		return tcf2.EncodeCanonical(v2)
+	}
+
+	if consent.Version() != 1 {
+		return "", fmt.Errorf("consent strings of version %d can't be encoded", consent.Version())
+	}
+	if mode == Lossless {
+		return tcf1.EncodeLossless(consent)
+	}
+	return tcf1.EncodeCanonical(consent)
+}
+
 // ParseVersion parses version from base64-decoded consent string
 func ParseVersion(decodedConsent []byte) (uint8, error) {
 	if len(decodedConsent) == 0 {
@@ -84,6 +84,48 @@ func TestParseValidString(t *testing.T) {
 	assertUInt16sEqual(t, 14, parsed.VendorListVersion())
 }
 
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name            string
+		consent         string
+		expectLossless  string
+		expectCanonical string
+	}{
+		{
+			name:            "tcf1_bitfield_with_trailing_bits",
+			consent:         "BONV8oqONXwgmADACHENAO7pqzAAppYAAAA",
+			expectLossless:  "BONV8oqONXwgmADACHENAO7pqzAAppYAAAA",
+			expectCanonical: "BONV8oqONXwgmADACHENAO7pqzAAppY",
+		},
+		{
+			name:            "tcf2_with_unknown_segment",
+			consent:         "CPtGDMAPtGDMALMAAAENA_C_AAAAAAAAACiQAAAAAAAA.4A",
+			expectLossless:  "CPtGDMAPtGDMALMAAAENA_C_AAAAAAAAACiQAAAAAAAA.4A",
+			expectCanonical: "CPtGDMAPtGDMALMAAAENA_C_AAAAAAAAACiQAAAAAAAA",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consent, err := ParseString(tt.consent)
+			assertNilError(t, err)
+			lossless, err := Encode(consent, Lossless)
+			assertNilError(t, err)
+			assertStringsEqual(t, tt.expectLossless, lossless)
+			canonical, err := Encode(consent, Canonical)
+			assertNilError(t, err)
+			assertStringsEqual(t, tt.expectCanonical, canonical)
+		})
+	}
+
+	consent, err := ParseString("BONV8oqONXwgmADACHENAO7pqzAAppY")
+	assertNilError(t, err)
+	_, err = Encode(consent, 2)
+	if err == nil {
+		t.Fatal("Expected an error on an undefined EncodingMode")
+	}
+	assertStringsEqual(t, "EncodingMode 2 is undefined", err.Error())
+}
+
 func assertInvalid(t *testing.T, urlEncodedString string, expectError string) {
 	t.Helper()
 	data, err := base64.RawURLEncoding.DecodeString(urlEncodedString)
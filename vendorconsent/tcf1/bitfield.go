@@ -38,6 +38,8 @@ type consentBitField struct {
 	consentMetadata
 	firstThree byte
 	others     []byte
+	// encoded is the string given to ParseString, if the consent string came from there
+	encoded string
 }
 
 func (f *consentBitField) VendorConsent(id uint16) bool {
@@ -0,0 +1,266 @@
+package vendorconsent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/prebid/go-gdpr/bitutils"
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+// ConsentBuilder holds the values of a TCF 1.1 consent string, and encodes them.
+// The output of Encode can be read back with Parse, and the output of EncodeString with ParseString.
+//
+// Fields which hold IDs are sets: their order doesn't matter, and duplicates are ignored.
+type ConsentBuilder struct {
+	// Created and LastUpdated are stored with a precision of a decisecond.
+	// Zero values are encoded as the Unix epoch.
+	Created     time.Time
+	LastUpdated time.Time
+
+	CmpID         uint16
+	CmpVersion    uint16
+	ConsentScreen uint8
+
+	// ConsentLanguage is the two-letter ISO 639-1 language code used by the CMP to ask for consent.
+	ConsentLanguage string
+
+	// VendorListVersion must be greater than or equal to 1.
+	VendorListVersion uint16
+
+	// PurposesAllowed lists the purposes (1 to 24) the user consented to.
+	PurposesAllowed []consentconstants.Purpose
+
+	// VendorConsents lists the vendors with consent.
+	VendorConsents []uint16
+	// MaxVendorID is the MaxVendorId of the consent string. If it is 0, the largest ID in VendorConsents is used.
+	// Either way, it must be greater than or equal to 1.
+	MaxVendorID uint16
+
+	// VendorEncoding picks how the vendors are encoded. It defaults to ShortestEncoding.
+	VendorEncoding VendorEncoding
+	// DefaultConsent is the consent value of the vendors which aren't listed in the RangeSection.
+	// The RangeSection then lists the vendors without consent. This is ignored unless VendorEncoding is RangeEncoding.
+	DefaultConsent bool
+
+	// rawLetters writes the two-letter codes as the raw 6-bit values of a parsed consent string, for EncodeCanonical
+	rawLetters bool
+}
+
+// VendorEncoding picks how the builder encodes the vendors.
+type VendorEncoding uint8
+
+const (
+	// ShortestEncoding encodes the vendors in whichever of the BitField and the RangeSection is shorter,
+	// along with the DefaultConsent which needs the fewest entries. The BitField wins ties.
+	ShortestEncoding VendorEncoding = iota
+	// BitFieldEncoding always encodes the vendors in a BitField.
+	BitFieldEncoding
+	// RangeEncoding always encodes the vendors in a RangeSection, with the builder's DefaultConsent.
+	RangeEncoding
+)
+
+// VendorSectionSizes holds the size in bits of each way to encode the vendors.
+// The MaxVendorId and EncodingType fields are needed either way, so they aren't counted.
+type VendorSectionSizes struct {
+	BitField uint
+	// RangeSection is the size of a RangeSection with a DefaultConsent of false, which lists the vendors with consent.
+	RangeSection uint
+	// RangeSectionDefaultConsent is the size of a RangeSection with a DefaultConsent of true,
+	// which lists the vendors without consent.
+	RangeSectionDefaultConsent uint
+}
+
+// EncodedSizes reports the size of each way to encode the vendor ids.
+// If maxVendorID is 0, the largest of the vendor ids is used instead.
+func EncodedSizes(vendorIDs []uint16, maxVendorID uint16) (VendorSectionSizes, error) {
+	vendors, _, err := vendorFlags(vendorIDs, maxVendorID)
+	if err != nil {
+		return VendorSectionSizes{}, err
+	}
+	return sectionSizes(vendors), nil
+}
+
+func sectionSizes(vendors []bool) VendorSectionSizes {
+	return VendorSectionSizes{
+		BitField:                   uint(len(vendors)),
+		RangeSection:               rangeSectionSize(rangeEntries(vendors, true)),
+		RangeSectionDefaultConsent: rangeSectionSize(rangeEntries(vendors, false)),
+	}
+}
+
+// rangeSectionSize counts the DefaultConsent, the NumEntries and the entries
+func rangeSectionSize(entries []rangeException) uint {
+	size := uint(1 + 12)
+	for _, entry := range entries {
+		if entry.startID == entry.endID {
+			size += 17
+		} else {
+			size += 33
+		}
+	}
+	return size
+}
+
+// EncodeString encodes the consent string, base64 encoded. This can be read back with ParseString.
+func (b ConsentBuilder) EncodeString() (string, error) {
+	data, err := b.Encode()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Encode encodes the consent string. The result is *not* encoded (by base64 or any other encoding),
+// and can be read back with Parse.
+func (b ConsentBuilder) Encode() ([]byte, error) {
+	var w bitutils.BitWriter
+
+	if b.VendorListVersion == 0 {
+		return nil, errInvalidVendorListVersion
+	}
+	if err := bitutils.CheckMaxValue("CmpID", uint64(b.CmpID), 12); err != nil {
+		return nil, err
+	}
+	if err := bitutils.CheckMaxValue("CmpVersion", uint64(b.CmpVersion), 12); err != nil {
+		return nil, err
+	}
+	if err := bitutils.CheckMaxValue("ConsentScreen", uint64(b.ConsentScreen), 6); err != nil {
+		return nil, err
+	}
+	if err := bitutils.CheckMaxValue("VendorListVersion", uint64(b.VendorListVersion), 12); err != nil {
+		return nil, err
+	}
+
+	vendors, maxVendorID, err := vendorFlags(b.VendorConsents, b.MaxVendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	w.WriteBits(1, 6) // Version
+	if err := w.WriteTimestamp("Created", b.Created); err != nil {
+		return nil, err
+	}
+	if err := w.WriteTimestamp("LastUpdated", b.LastUpdated); err != nil {
+		return nil, err
+	}
+	w.WriteBits(uint64(b.CmpID), 12)
+	w.WriteBits(uint64(b.CmpVersion), 12)
+	w.WriteBits(uint64(b.ConsentScreen), 6)
+	writeLetters := w.WriteLetters
+	if b.rawLetters {
+		writeLetters = w.WriteRawLetters
+	}
+	if err := writeLetters("ConsentLanguage", b.ConsentLanguage); err != nil {
+		return nil, err
+	}
+	w.WriteBits(uint64(b.VendorListVersion), 12)
+
+	purposes := make([]bool, 24)
+	for _, purpose := range b.PurposesAllowed {
+		if purpose < 1 || purpose > 24 {
+			return nil, fmt.Errorf("PurposesAllowed holds %d, but only values in [1, 24] are valid", purpose)
+		}
+		purposes[purpose-1] = true
+	}
+	for _, purpose := range purposes {
+		w.WriteBool(purpose)
+	}
+
+	isRangeEncoding, defaultConsent := false, false
+	switch b.VendorEncoding {
+	case ShortestEncoding:
+		sizes := sectionSizes(vendors)
+		if sizes.RangeSectionDefaultConsent < sizes.RangeSection && sizes.RangeSectionDefaultConsent < sizes.BitField {
+			isRangeEncoding, defaultConsent = true, true
+		} else if sizes.RangeSection < sizes.BitField {
+			isRangeEncoding = true
+		}
+	case BitFieldEncoding:
+		// Neither flag is set for a BitField
+	case RangeEncoding:
+		isRangeEncoding, defaultConsent = true, b.DefaultConsent
+	default:
+		return nil, fmt.Errorf("VendorEncoding %d is undefined", b.VendorEncoding)
+	}
+
+	w.WriteBits(uint64(maxVendorID), 16)
+	w.WriteBool(isRangeEncoding)
+	if isRangeEncoding {
+		w.WriteBool(defaultConsent)
+		if err := writeRangeEntries(&w, rangeEntries(vendors, !defaultConsent)); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, vendor := range vendors {
+			w.WriteBool(vendor)
+		}
+	}
+
+	return w.Bytes(), nil
+}
+
+// vendorFlags returns one flag per vendor ID in [1, maxVendorID], which is set if the vendor has consent.
+// If maxVendorID is 0, the largest of the vendor ids is used instead.
+func vendorFlags(vendorIDs []uint16, maxVendorID uint16) ([]bool, uint16, error) {
+	var largestID uint16
+	for _, id := range vendorIDs {
+		if id == 0 {
+			return nil, 0, fmt.Errorf("VendorConsents holds vendor 0, but the min vendor ID is 1")
+		}
+		if id > largestID {
+			largestID = id
+		}
+	}
+	if maxVendorID == 0 {
+		maxVendorID = largestID
+	} else if largestID > maxVendorID {
+		return nil, 0, fmt.Errorf("VendorConsents holds vendor %d, but the max vendor ID is %d", largestID, maxVendorID)
+	}
+	if maxVendorID < 1 {
+		return nil, 0, fmt.Errorf("the MaxVendorID is 0, but this value must be greater than or equal to 1")
+	}
+
+	vendors := make([]bool, maxVendorID)
+	for _, id := range vendorIDs {
+		vendors[id-1] = true
+	}
+	return vendors, maxVendorID, nil
+}
+
+// rangeEntries returns a single or range entry for each run of vendors whose flag matches the exception value
+func rangeEntries(vendors []bool, exception bool) []rangeException {
+	var entries []rangeException
+	for i := 0; i < len(vendors); i++ {
+		if vendors[i] != exception {
+			continue
+		}
+		start := i
+		for i+1 < len(vendors) && vendors[i+1] == exception {
+			i++
+		}
+		// Careful here... vendor IDs start at index 1...
+		entries = append(entries, rangeException{startID: uint16(start + 1), endID: uint16(i + 1)})
+	}
+	return entries
+}
+
+// writeRangeEntries writes the NumEntries followed by the entries
+func writeRangeEntries(w *bitutils.BitWriter, entries []rangeException) error {
+	if err := bitutils.CheckMaxValue("the number of RangeSection entries", uint64(len(entries)), 12); err != nil {
+		return err
+	}
+	w.WriteBits(uint64(len(entries)), 12)
+	for _, entry := range entries {
+		if entry.startID == entry.endID {
+			w.WriteBool(false)
+			w.WriteBits(uint64(entry.startID), 16)
+		} else {
+			w.WriteBool(true)
+			w.WriteBits(uint64(entry.startID), 16)
+			w.WriteBits(uint64(entry.endID), 16)
+		}
+	}
+	return nil
+}
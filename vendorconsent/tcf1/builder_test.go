@@ -0,0 +1,234 @@
+package vendorconsent
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+func TestConsentBuilderBitField(t *testing.T) {
+	builder := ConsentBuilder{
+		Created:           time.Unix(1525000000, 0),
+		LastUpdated:       time.Unix(1525000100, 0),
+		CmpID:             3,
+		CmpVersion:        2,
+		ConsentScreen:     7,
+		ConsentLanguage:   "EN",
+		VendorListVersion: 14,
+		PurposesAllowed:   []consentconstants.Purpose{1, 2, 5},
+		VendorConsents:    []uint16{1, 2, 4, 7, 9, 10},
+	}
+	encoded, err := builder.EncodeString()
+	assertNilError(t, err)
+	consent, err := ParseString(encoded)
+	assertNilError(t, err)
+
+	assertUInt8sEqual(t, 1, consent.Version())
+	assertIntsEqual(t, 1525000000, int(consent.Created().Unix()))
+	assertIntsEqual(t, 1525000100, int(consent.LastUpdated().Unix()))
+	assertUInt16sEqual(t, 3, consent.CmpID())
+	assertUInt16sEqual(t, 2, consent.CmpVersion())
+	assertUInt8sEqual(t, 7, consent.ConsentScreen())
+	assertStringsEqual(t, "EN", consent.ConsentLanguage())
+	assertUInt16sEqual(t, 14, consent.VendorListVersion())
+	assertUInt16sEqual(t, 10, consent.MaxVendorID())
+
+	purposesAllowed := buildMap(1, 2, 5)
+	for i := uint8(1); i <= 24; i++ {
+		_, ok := purposesAllowed[uint(i)]
+		assertBoolsEqual(t, ok, consent.PurposeAllowed(consentconstants.Purpose(i)))
+	}
+	vendorsWithConsent := buildMap(1, 2, 4, 7, 9, 10)
+	for i := uint16(1); i <= consent.MaxVendorID(); i++ {
+		_, ok := vendorsWithConsent[uint(i)]
+		assertBoolsEqual(t, ok, consent.VendorConsent(i))
+	}
+}
+
+func TestConsentBuilderRangeSection(t *testing.T) {
+	for _, defaultConsent := range []bool{false, true} {
+		builder := ConsentBuilder{
+			ConsentLanguage:   "FR",
+			VendorListVersion: 100,
+			VendorConsents:    []uint16{5, 6, 7, 8, 300, 9, 3, 700},
+			MaxVendorID:       800,
+			VendorEncoding:    RangeEncoding,
+			DefaultConsent:    defaultConsent,
+		}
+		data, err := builder.Encode()
+		assertNilError(t, err)
+		consent, err := Parse(data)
+		assertNilError(t, err)
+		assertBoolsEqual(t, defaultConsent, consent.(*rangeSection).defaultValue)
+		assertUInt16sEqual(t, 800, consent.MaxVendorID())
+
+		vendorsWithConsent := buildMap(3, 5, 6, 7, 8, 9, 300, 700)
+		for i := uint16(1); i <= consent.MaxVendorID(); i++ {
+			_, ok := vendorsWithConsent[uint(i)]
+			assertBoolsEqual(t, ok, consent.VendorConsent(i))
+		}
+	}
+}
+
+func TestConsentBuilderShortestEncoding(t *testing.T) {
+	allButTwo := make([]uint16, 0, 600)
+	for i := uint16(1); i <= 600; i++ {
+		if i != 50 && i != 51 {
+			allButTwo = append(allButTwo, i)
+		}
+	}
+	tests := []struct {
+		name                 string
+		vendorIDs            []uint16
+		expectRange          bool
+		expectDefaultConsent bool
+	}{
+		{name: "dense", vendorIDs: []uint16{1, 3, 4, 6, 8, 9, 11}, expectRange: false},
+		{name: "sparse", vendorIDs: []uint16{3, 400}, expectRange: true, expectDefaultConsent: false},
+		{name: "mostly_consent", vendorIDs: allButTwo, expectRange: true, expectDefaultConsent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := ConsentBuilder{
+				ConsentLanguage:   "EN",
+				VendorListVersion: 1,
+				VendorConsents:    tt.vendorIDs,
+			}
+			data, err := builder.Encode()
+			assertNilError(t, err)
+			consent, err := Parse(data)
+			assertNilError(t, err)
+
+			section, isRange := consent.(*rangeSection)
+			assertBoolsEqual(t, tt.expectRange, isRange)
+			if isRange {
+				assertBoolsEqual(t, tt.expectDefaultConsent, section.defaultValue)
+			}
+			vendorsWithConsent := make(map[uint16]bool, len(tt.vendorIDs))
+			for _, id := range tt.vendorIDs {
+				vendorsWithConsent[id] = true
+			}
+			for i := uint16(1); i <= consent.MaxVendorID(); i++ {
+				assertBoolsEqual(t, vendorsWithConsent[i], consent.VendorConsent(i))
+			}
+		})
+	}
+}
+
+func TestEncodedSizes(t *testing.T) {
+	sizes, err := EncodedSizes([]uint16{2, 3, 4, 9}, 10)
+	assertNilError(t, err)
+	expected := VendorSectionSizes{
+		BitField:                   10,
+		RangeSection:               1 + 12 + 33 + 17,
+		RangeSectionDefaultConsent: 1 + 12 + 17 + 33 + 17,
+	}
+	if sizes != expected {
+		t.Errorf("Sizes were not equal. Expected %+v, actual %+v", expected, sizes)
+	}
+
+	_, err = EncodedSizes(nil, 0)
+	assertStringsEqual(t, "the MaxVendorID is 0, but this value must be greater than or equal to 1", err.Error())
+}
+
+func TestConsentBuilderReproducesStrings(t *testing.T) {
+	tests := []string{
+		"BONV8oqONXwgmADACHENAO7pqzAAppY",
+		"BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			consent, err := Parse(decode(t, test))
+			assertNilError(t, err)
+			builder := ConsentBuilder{
+				Created:           consent.Created(),
+				LastUpdated:       consent.LastUpdated(),
+				CmpID:             consent.CmpID(),
+				CmpVersion:        consent.CmpVersion(),
+				ConsentScreen:     consent.ConsentScreen(),
+				ConsentLanguage:   consent.ConsentLanguage(),
+				VendorListVersion: consent.VendorListVersion(),
+				MaxVendorID:       consent.MaxVendorID(),
+				VendorEncoding:    BitFieldEncoding,
+			}
+			for i := consentconstants.Purpose(1); i <= 24; i++ {
+				if consent.PurposeAllowed(i) {
+					builder.PurposesAllowed = append(builder.PurposesAllowed, i)
+				}
+			}
+			for i := uint16(1); i <= consent.MaxVendorID(); i++ {
+				if consent.VendorConsent(i) {
+					builder.VendorConsents = append(builder.VendorConsents, i)
+				}
+			}
+			if section, ok := consent.(*rangeSection); ok {
+				builder.VendorEncoding = RangeEncoding
+				builder.DefaultConsent = section.defaultValue
+			}
+
+			data, err := builder.Encode()
+			assertNilError(t, err)
+			assertStringsEqual(t, test, base64.RawURLEncoding.EncodeToString(data))
+		})
+	}
+}
+
+func TestConsentBuilderErrors(t *testing.T) {
+	valid := ConsentBuilder{
+		ConsentLanguage:   "EN",
+		VendorListVersion: 1,
+		VendorConsents:    []uint16{1},
+	}
+	tests := []struct {
+		name        string
+		modify      func(b *ConsentBuilder)
+		expectError string
+	}{
+		{
+			name:        "vendor_list_version_0",
+			modify:      func(b *ConsentBuilder) { b.VendorListVersion = 0 },
+			expectError: "the consent string encoded a VendorListVersion of 0, but this value must be greater than or equal to 1",
+		},
+		{
+			name:        "consent_screen_too_large",
+			modify:      func(b *ConsentBuilder) { b.ConsentScreen = 64 },
+			expectError: "ConsentScreen is 64, but the consent string only has room for values up to 63",
+		},
+		{
+			name:        "language_lowercase_digit",
+			modify:      func(b *ConsentBuilder) { b.ConsentLanguage = "e1" },
+			expectError: `ConsentLanguage must only contain the letters A to Z. Got "e1"`,
+		},
+		{
+			name:        "purpose_0",
+			modify:      func(b *ConsentBuilder) { b.PurposesAllowed = []consentconstants.Purpose{0} },
+			expectError: "PurposesAllowed holds 0, but only values in [1, 24] are valid",
+		},
+		{
+			name:        "no_vendors",
+			modify:      func(b *ConsentBuilder) { b.VendorConsents = nil },
+			expectError: "the MaxVendorID is 0, but this value must be greater than or equal to 1",
+		},
+		{
+			name: "vendor_above_max_vendor_id",
+			modify: func(b *ConsentBuilder) {
+				b.VendorConsents = []uint16{12}
+				b.MaxVendorID = 10
+			},
+			expectError: "VendorConsents holds vendor 12, but the max vendor ID is 10",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := valid
+			tt.modify(&builder)
+			_, err := builder.Encode()
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			assertStringsEqual(t, tt.expectError, err.Error())
+		})
+	}
+}
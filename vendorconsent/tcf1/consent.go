@@ -21,7 +21,18 @@ func ParseString(consent string) (api.VendorConsents, error) {
 	}
 	decoded = decoded[:n:n]
 
-	return Parse(decoded)
+	parsed, err := Parse(decoded)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the original string, so that EncodeLossless doesn't lose the pad bits of its last character
+	switch parsed := parsed.(type) {
+	case *consentBitField:
+		parsed.encoded = consent
+	case *rangeSection:
+		parsed.encoded = consent
+	}
+	return parsed, nil
 }
 
 // Parse the vendor consent data from the string. This string should *not* be encoded (by base64 or any other encoding).
@@ -0,0 +1,58 @@
+package vendorconsent
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"github.com/prebid/go-gdpr/api"
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+// EncodeLossless encodes a consent string returned by ParseString or Parse back into its base64 form.
+// Consent strings returned by ParseString come back exactly as they were passed, including trailing bits
+// and the pad bits of the last character.
+func EncodeLossless(consent api.VendorConsents) (string, error) {
+	var data consentMetadata
+	var encoded string
+	switch consent := consent.(type) {
+	case *consentBitField:
+		data, encoded = consent.consentMetadata, consent.encoded
+	case *rangeSection:
+		data, encoded = consent.consentMetadata, consent.encoded
+	default:
+		return "", errors.New("lossless encoding needs a consent string returned by ParseString or Parse")
+	}
+	if encoded != "" {
+		return encoded, nil
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// EncodeCanonical encodes the values of the consent string from scratch. Consent strings which hold the same values
+// always produce the same output: the vendors use their shortest encoding, and trailing bits are dropped.
+// The ConsentLanguage keeps its 6-bit values, even if they aren't letters.
+func EncodeCanonical(consent api.VendorConsents) (string, error) {
+	builder := ConsentBuilder{
+		Created:           consent.Created(),
+		LastUpdated:       consent.LastUpdated(),
+		CmpID:             consent.CmpID(),
+		CmpVersion:        consent.CmpVersion(),
+		ConsentScreen:     consent.ConsentScreen(),
+		ConsentLanguage:   consent.ConsentLanguage(),
+		VendorListVersion: consent.VendorListVersion(),
+		MaxVendorID:       consent.MaxVendorID(),
+		rawLetters:        true,
+	}
+	for i := consentconstants.Purpose(1); i <= 24; i++ {
+		if consent.PurposeAllowed(i) {
+			builder.PurposesAllowed = append(builder.PurposesAllowed, i)
+		}
+	}
+	// i wraps around to 0 after the last vendor ID, 65535
+	for i := uint16(1); i <= consent.MaxVendorID() && i != 0; i++ {
+		if consent.VendorConsent(i) {
+			builder.VendorConsents = append(builder.VendorConsents, i)
+		}
+	}
+	return builder.EncodeString()
+}
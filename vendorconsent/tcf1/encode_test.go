@@ -0,0 +1,69 @@
+package vendorconsent
+
+import "testing"
+
+func TestEncodeLossless(t *testing.T) {
+	tests := []string{
+		"BONV8oqONXwgmADACHENAO7pqzAAppY",
+		"BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw",
+		// With trailing bits
+		"BONV8oqONXwgmADACHENAO7pqzAAppYAAAA",
+		// With non-zero pad bits in the last character
+		"BONV8oqONXwgmADACHENAO7pqzAAppZ",
+		"BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFx",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			consent, err := ParseString(test)
+			assertNilError(t, err)
+			encoded, err := EncodeLossless(consent)
+			assertNilError(t, err)
+			assertStringsEqual(t, test, encoded)
+		})
+	}
+}
+
+func TestEncodeCanonical(t *testing.T) {
+	builder := ConsentBuilder{
+		ConsentLanguage:   "EN",
+		VendorListVersion: 5,
+		VendorConsents:    []uint16{2, 3, 4, 5, 600},
+	}
+	canonical, err := builder.EncodeString()
+	assertNilError(t, err)
+
+	variants := []ConsentBuilder{builder, builder, builder}
+	variants[0].VendorEncoding = BitFieldEncoding
+	variants[1].VendorEncoding = RangeEncoding
+	variants[2].VendorEncoding = RangeEncoding
+	variants[2].DefaultConsent = true
+	for _, variant := range variants {
+		encoded, err := variant.EncodeString()
+		assertNilError(t, err)
+		for _, test := range []string{encoded, encoded + "AAAA"} {
+			consent, err := ParseString(test)
+			assertNilError(t, err)
+			actual, err := EncodeCanonical(consent)
+			assertNilError(t, err)
+			assertStringsEqual(t, canonical, actual)
+		}
+	}
+}
+
+func TestEncodeCanonicalKeepsRawLetters(t *testing.T) {
+	builder := ConsentBuilder{ConsentLanguage: "EN", VendorListVersion: 5, VendorConsents: []uint16{2, 3}}
+	data, err := builder.Encode()
+	assertNilError(t, err)
+	// The ConsentLanguage is stored in bits 108-119. Store the 6-bit values 32, which reads as 'a', and 63.
+	data[13] = data[13]&0xf0 | 0x08
+	data[14] = 0x3f
+	consent, err := Parse(data)
+	assertNilError(t, err)
+	assertStringsEqual(t, string([]byte{'a', 'A' + 63}), consent.ConsentLanguage())
+
+	encoded, err := EncodeCanonical(consent)
+	assertNilError(t, err)
+	canonical, err := ParseString(encoded)
+	assertNilError(t, err)
+	assertStringsEqual(t, consent.ConsentLanguage(), canonical.ConsentLanguage())
+}
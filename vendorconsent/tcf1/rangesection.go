@@ -119,6 +119,8 @@ type rangeSection struct {
 	consentMetadata
 	defaultValue bool
 	exceptions   []rangeException
+	// encoded is the string given to ParseString, if the consent string came from there
+	encoded string
 }
 
 // VendorConsents implementation
@@ -4,9 +4,7 @@ import (
 	"fmt"
 )
 
-func parseBitField(metadata ConsentMetadata, vendorBitsRequired uint16, startbit uint) (*consentBitField, uint, error) {
-	data := metadata.data
-
+func parseBitField(data []byte, vendorBitsRequired uint16, startbit uint) (*consentBitField, uint, error) {
 	// add 7 to force rounding to next integer value
 	bytesRequired := (uint(vendorBitsRequired) + startbit + 7) / 8
 	if uint(len(data)) < bytesRequired {
@@ -43,6 +41,29 @@ func (f *consentBitField) VendorConsent(id uint16) bool {
 	return isSet(f.data, f.startbit+uint(id)-1)
 }
 
+// VendorIDs returns the IDs of every vendor whose bit is set, in ascending order
+func (f *consentBitField) VendorIDs() []uint16 {
+	if f == nil {
+		return nil
+	}
+
+	var ids []uint16
+	end := f.startbit + uint(f.maxVendorID)
+	for bit := f.startbit; bit < end; {
+		// Skip whole bytes without any vendor, which are common in sparse BitFields
+		if bit%8 == 0 && f.data[bit/8] == 0 {
+			bit += 8
+			continue
+		}
+		if isSet(f.data, bit) {
+			// Careful here... vendor IDs start at index 1...
+			ids = append(ids, uint16(bit-f.startbit+1))
+		}
+		bit++
+	}
+	return ids
+}
+
 // byteToBool returns false if val is 0, and true otherwise
 func byteToBool(val byte) bool {
 	return val != 0
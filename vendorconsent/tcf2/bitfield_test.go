@@ -1,6 +1,7 @@
 package vendorconsent
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/prebid/go-gdpr/consentconstants"
@@ -35,8 +36,33 @@ func TestBitField(t *testing.T) {
 func TestParseBitFieldRounding(t *testing.T) {
 	// crafted metadata to have 232 bits of data
 	data := make([]byte, 29)
-	metadata := ConsentMetadata{data: data}
 	// having 3 vendors with 230 bits of header should require 30 bytes of data (233 bits rounded to upper byte)
-	_, _, err := parseBitField(metadata, 3, 230)
+	_, _, err := parseBitField(data, 3, 230)
 	assertError(t, err)
 }
+
+func TestBitFieldVendorIDs(t *testing.T) {
+	consent, err := Parse(decode(t, "COwGVJOOwGVJOADACHENAOCAAO6as_-AAAhoAFNLAAoAAAA"))
+	assertNilError(t, err)
+	assertUInt16SlicesEqual(t, []uint16{1, 2, 4, 7, 9, 10}, consent.VendorConsentIDs())
+}
+
+func TestBitFieldVendorIDsSkipsEmptyBytes(t *testing.T) {
+	// The BitField starts at bit 4 and spans 30 vendors, with vendors 1, 12 and 30 set.
+	// The byte holding vendors 13 to 20 is empty.
+	data := []byte{0x08, 0x01, 0x00, 0x00, 0x40}
+	bitField, _, err := parseBitField(data, 30, 4)
+	assertNilError(t, err)
+	assertUInt16SlicesEqual(t, []uint16{1, 12, 30}, bitField.VendorIDs())
+
+	empty, _, err := parseBitField(make([]byte, 5), 30, 4)
+	assertNilError(t, err)
+	assertIntsEqual(t, 0, len(empty.VendorIDs()))
+}
+
+func assertUInt16SlicesEqual(t *testing.T, expected []uint16, actual []uint16) {
+	t.Helper()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Slices were not equal. Expected %v, actual %v", expected, actual)
+	}
+}
@@ -0,0 +1,470 @@
+package vendorconsent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prebid/go-gdpr/bitutils"
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+// ConsentBuilder holds the values of a TCF 2.0 consent string, and encodes them.
+// The output of Encode can be read back with Parse, and the output of EncodeString with ParseString.
+//
+// Fields which hold IDs are sets: their order doesn't matter, and duplicates are ignored.
+type ConsentBuilder struct {
+	// Created and LastUpdated are stored with a precision of a decisecond.
+	// Zero values are encoded as the Unix epoch.
+	Created     time.Time
+	LastUpdated time.Time
+
+	CmpID         uint16
+	CmpVersion    uint16
+	ConsentScreen uint8
+
+	// ConsentLanguage is the two-letter ISO 639-1 language code used by the CMP to ask for consent.
+	ConsentLanguage string
+
+	// VendorListVersion must be greater than or equal to 1.
+	VendorListVersion uint16
+	TCFPolicyVersion  uint8
+
+	IsServiceSpecific   bool
+	UseNonStandardTexts bool
+
+	// SpecialFeatureOptIns lists the special features (1 to 12) the user opted in to.
+	SpecialFeatureOptIns []consentconstants.SpecialFeature
+	// PurposesConsent lists the purposes (1 to 24) the user consented to.
+	PurposesConsent []consentconstants.Purpose
+	// PurposesLITransparency lists the purposes (1 to 24) for which legitimate interest was established.
+	PurposesLITransparency []consentconstants.Purpose
+	PurposeOneTreatment    bool
+
+	// PublisherCC is the two-letter ISO 3166-1 alpha-2 country code of the publisher.
+	PublisherCC string
+
+	// VendorConsents lists the vendors with consent.
+	VendorConsents []uint16
+	// MaxVendorID is the MaxVendorId of the vendor consents. If it is 0, the largest ID in VendorConsents is used.
+	MaxVendorID uint16
+	// VendorLegitimateInterests lists the vendors with legitimate interest established.
+	VendorLegitimateInterests []uint16
+	// VendorLegitInterestMaxID is the MaxVendorId of the vendor legitimate interests.
+	// If it is 0, the largest ID in VendorLegitimateInterests is used.
+	VendorLegitInterestMaxID uint16
+
+	// PubRestrictions must not hold the same purpose and restriction type pair more than once.
+	PubRestrictions []PubRestriction
+
+	// DisclosedVendors lists the vendors disclosed to the user.
+	// The Disclosed Vendors segment is only encoded if this isn't nil.
+	DisclosedVendors []uint16
+	// DisclosedVendorMaxID is the MaxVendorId of the Disclosed Vendors segment.
+	// If it is 0, the largest ID in DisclosedVendors is used.
+	DisclosedVendorMaxID uint16
+	// AllowedVendors lists the vendors the publisher allows to use the OOB legal bases.
+	// The Allowed Vendors segment is only encoded if this isn't nil.
+	AllowedVendors []uint16
+	// AllowedVendorMaxID is the MaxVendorId of the Allowed Vendors segment.
+	// If it is 0, the largest ID in AllowedVendors is used.
+	AllowedVendorMaxID uint16
+
+	// PublisherTC holds the values of the Publisher TC segment. That segment is only encoded if this isn't nil.
+	PublisherTC *PublisherTCBuilder
+
+	// VendorEncoding picks how every list of vendors is encoded. It defaults to ShortestEncoding.
+	VendorEncoding VendorEncoding
+
+	// rawLetters writes the two-letter codes as the raw 6-bit values of a parsed consent string, for EncodeCanonical
+	rawLetters bool
+}
+
+// PublisherTCBuilder holds the values of the Publisher TC segment.
+type PublisherTCBuilder struct {
+	// PurposesConsent lists the purposes (1 to 24) the user consented to for the publisher.
+	PurposesConsent []consentconstants.Purpose
+	// PurposesLITransparency lists the purposes (1 to 24) for which the publisher established legitimate interest.
+	PurposesLITransparency []consentconstants.Purpose
+
+	// NumCustomPurposes is the number of custom purposes the publisher defined, up to 63.
+	// If it is 0, the largest ID in CustomPurposesConsent and CustomPurposesLITransparency is used.
+	NumCustomPurposes uint8
+	// CustomPurposesConsent lists the custom purposes the user consented to.
+	CustomPurposesConsent []uint8
+	// CustomPurposesLITransparency lists the custom purposes for which legitimate interest was established.
+	CustomPurposesLITransparency []uint8
+}
+
+// EncodeString encodes the core string followed by the optional segments, base64 encoded.
+// This can be read back with ParseString.
+func (b ConsentBuilder) EncodeString() (string, error) {
+	data, err := b.Encode()
+	if err != nil {
+		return "", err
+	}
+	consent := base64.RawURLEncoding.EncodeToString(data)
+
+	if b.DisclosedVendors != nil {
+		segment, err := encodeVendorsSegment(segmentTypeDisclosedVendors, "DisclosedVendors", b.DisclosedVendors, b.DisclosedVendorMaxID, b.VendorEncoding)
+		if err != nil {
+			return "", err
+		}
+		consent += "." + segment
+	}
+	if b.AllowedVendors != nil {
+		segment, err := encodeVendorsSegment(segmentTypeAllowedVendors, "AllowedVendors", b.AllowedVendors, b.AllowedVendorMaxID, b.VendorEncoding)
+		if err != nil {
+			return "", err
+		}
+		consent += "." + segment
+	}
+	if b.PublisherTC != nil {
+		segment, err := b.PublisherTC.encode()
+		if err != nil {
+			return "", err
+		}
+		consent += "." + segment
+	}
+	return consent, nil
+}
+
+// encodeVendorsSegment encodes a Disclosed Vendors or an Allowed Vendors segment, base64 encoded
+func encodeVendorsSegment(segmentType uint8, field string, vendorIDs []uint16, maxVendorID uint16, encoding VendorEncoding) (string, error) {
+	var w bitutils.BitWriter
+	w.WriteBits(uint64(segmentType), 3)
+	if err := writeVendorSection(&w, field, vendorIDs, maxVendorID, encoding); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(w.Bytes()), nil
+}
+
+// encode encodes the Publisher TC segment, base64 encoded
+func (p *PublisherTCBuilder) encode() (string, error) {
+	numCustomPurposes := p.NumCustomPurposes
+	if numCustomPurposes == 0 {
+		for _, ids := range [][]uint8{p.CustomPurposesConsent, p.CustomPurposesLITransparency} {
+			for _, id := range ids {
+				if id > numCustomPurposes {
+					numCustomPurposes = id
+				}
+			}
+		}
+	}
+	if err := bitutils.CheckMaxValue("NumCustomPurposes", uint64(numCustomPurposes), 6); err != nil {
+		return "", err
+	}
+
+	var w bitutils.BitWriter
+	w.WriteBits(uint64(segmentTypePublisherTC), 3)
+	if err := writeFlags(&w, "PublisherTC.PurposesConsent", purposeIDs(p.PurposesConsent), 24); err != nil {
+		return "", err
+	}
+	if err := writeFlags(&w, "PublisherTC.PurposesLITransparency", purposeIDs(p.PurposesLITransparency), 24); err != nil {
+		return "", err
+	}
+	w.WriteBits(uint64(numCustomPurposes), 6)
+	if err := writeFlags(&w, "PublisherTC.CustomPurposesConsent", customPurposeIDs(p.CustomPurposesConsent), uint16(numCustomPurposes)); err != nil {
+		return "", err
+	}
+	if err := writeFlags(&w, "PublisherTC.CustomPurposesLITransparency", customPurposeIDs(p.CustomPurposesLITransparency), uint16(numCustomPurposes)); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(w.Bytes()), nil
+}
+
+// Encode encodes the core string, without the optional segments. The result is *not* encoded (by base64 or any other encoding),
+// and can be read back with Parse.
+func (b ConsentBuilder) Encode() ([]byte, error) {
+	var w bitutils.BitWriter
+
+	if b.VendorListVersion == 0 {
+		return nil, errInvalidVendorListVersion
+	}
+	if err := bitutils.CheckMaxValue("CmpID", uint64(b.CmpID), 12); err != nil {
+		return nil, err
+	}
+	if err := bitutils.CheckMaxValue("CmpVersion", uint64(b.CmpVersion), 12); err != nil {
+		return nil, err
+	}
+	if err := bitutils.CheckMaxValue("ConsentScreen", uint64(b.ConsentScreen), 6); err != nil {
+		return nil, err
+	}
+	if err := bitutils.CheckMaxValue("VendorListVersion", uint64(b.VendorListVersion), 12); err != nil {
+		return nil, err
+	}
+	if err := bitutils.CheckMaxValue("TCFPolicyVersion", uint64(b.TCFPolicyVersion), 6); err != nil {
+		return nil, err
+	}
+
+	w.WriteBits(2, 6) // Version
+	if err := w.WriteTimestamp("Created", b.Created); err != nil {
+		return nil, err
+	}
+	if err := w.WriteTimestamp("LastUpdated", b.LastUpdated); err != nil {
+		return nil, err
+	}
+	w.WriteBits(uint64(b.CmpID), 12)
+	w.WriteBits(uint64(b.CmpVersion), 12)
+	w.WriteBits(uint64(b.ConsentScreen), 6)
+	writeLetters := w.WriteLetters
+	if b.rawLetters {
+		writeLetters = w.WriteRawLetters
+	}
+	if err := writeLetters("ConsentLanguage", b.ConsentLanguage); err != nil {
+		return nil, err
+	}
+	w.WriteBits(uint64(b.VendorListVersion), 12)
+	w.WriteBits(uint64(b.TCFPolicyVersion), 6)
+	w.WriteBool(b.IsServiceSpecific)
+	w.WriteBool(b.UseNonStandardTexts)
+
+	specialFeatures := make([]uint16, len(b.SpecialFeatureOptIns))
+	for i, feature := range b.SpecialFeatureOptIns {
+		specialFeatures[i] = uint16(feature)
+	}
+	if err := writeFlags(&w, "SpecialFeatureOptIns", specialFeatures, 12); err != nil {
+		return nil, err
+	}
+	if err := writeFlags(&w, "PurposesConsent", purposeIDs(b.PurposesConsent), 24); err != nil {
+		return nil, err
+	}
+	if err := writeFlags(&w, "PurposesLITransparency", purposeIDs(b.PurposesLITransparency), 24); err != nil {
+		return nil, err
+	}
+	w.WriteBool(b.PurposeOneTreatment)
+	if err := writeLetters("PublisherCC", b.PublisherCC); err != nil {
+		return nil, err
+	}
+
+	if err := writeVendorSection(&w, "VendorConsents", b.VendorConsents, b.MaxVendorID, b.VendorEncoding); err != nil {
+		return nil, err
+	}
+	if err := writeVendorSection(&w, "VendorLegitimateInterests", b.VendorLegitimateInterests, b.VendorLegitInterestMaxID, b.VendorEncoding); err != nil {
+		return nil, err
+	}
+	if err := writePubRestrictions(&w, b.PubRestrictions); err != nil {
+		return nil, err
+	}
+
+	return w.Bytes(), nil
+}
+
+// writeFlags writes one bit for each ID in [1, bitCount], which is set if the ID is in the ids
+func writeFlags(w *bitutils.BitWriter, field string, ids []uint16, bitCount uint16) error {
+	flags := make([]bool, bitCount)
+	for _, id := range ids {
+		if id < 1 || id > bitCount {
+			return fmt.Errorf("%s holds %d, but only values in [1, %d] are valid", field, id, bitCount)
+		}
+		flags[id-1] = true
+	}
+	for _, flag := range flags {
+		w.WriteBool(flag)
+	}
+	return nil
+}
+
+func purposeIDs(purposes []consentconstants.Purpose) []uint16 {
+	ids := make([]uint16, len(purposes))
+	for i, purpose := range purposes {
+		ids[i] = uint16(purpose)
+	}
+	return ids
+}
+
+func customPurposeIDs(purposes []uint8) []uint16 {
+	ids := make([]uint16, len(purposes))
+	for i, purpose := range purposes {
+		ids[i] = uint16(purpose)
+	}
+	return ids
+}
+
+// VendorEncoding picks how the builder encodes each list of vendors.
+type VendorEncoding uint8
+
+const (
+	// ShortestEncoding encodes each list of vendors in whichever of the BitField and the RangeSection
+	// is shorter. The BitField wins ties.
+	ShortestEncoding VendorEncoding = iota
+	// BitFieldEncoding always encodes the vendors in a BitField.
+	BitFieldEncoding
+	// RangeEncoding always encodes the vendors in a RangeSection.
+	RangeEncoding
+)
+
+// VendorSectionSizes holds the size in bits of each way to encode a list of vendors.
+// The MaxVendorId and IsRangeEncoding fields are needed either way, so they aren't counted.
+type VendorSectionSizes struct {
+	BitField     uint
+	RangeSection uint
+}
+
+// EncodedSizes reports the size of each way to encode the vendor ids.
+// If maxVendorID is 0, the largest of the vendor ids is used instead.
+func EncodedSizes(vendorIDs []uint16, maxVendorID uint16) (VendorSectionSizes, error) {
+	ranges, maxVendorID, err := vendorRanges("vendorIDs", vendorIDs, maxVendorID)
+	if err != nil {
+		return VendorSectionSizes{}, err
+	}
+	return sectionSizes(ranges, maxVendorID), nil
+}
+
+func sectionSizes(ranges []VendorRange, maxVendorID uint16) VendorSectionSizes {
+	sizes := VendorSectionSizes{
+		BitField:     uint(maxVendorID),
+		RangeSection: 12, // NumEntries
+	}
+	for _, vendors := range ranges {
+		if vendors.StartID == vendors.EndID {
+			sizes.RangeSection += 17
+		} else {
+			sizes.RangeSection += 33
+		}
+	}
+	return sizes
+}
+
+// vendorRanges returns the runs of consecutive vendor ids, in order, along with the MaxVendorId.
+// If maxVendorID is 0, the largest of the vendor ids is used instead.
+func vendorRanges(field string, vendorIDs []uint16, maxVendorID uint16) ([]VendorRange, uint16, error) {
+	sorted := make([]uint16, len(vendorIDs))
+	copy(sorted, vendorIDs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var ranges []VendorRange
+	for _, id := range sorted {
+		if id == 0 {
+			return nil, 0, fmt.Errorf("%s holds vendor 0, but the min vendor ID is 1", field)
+		}
+		last := len(ranges) - 1
+		if last >= 0 && (id <= ranges[last].EndID || id == ranges[last].EndID+1) {
+			ranges[last].EndID = id
+			continue
+		}
+		ranges = append(ranges, VendorRange{StartID: id, EndID: id})
+	}
+
+	var largestID uint16
+	if len(ranges) > 0 {
+		largestID = ranges[len(ranges)-1].EndID
+	}
+	if maxVendorID == 0 {
+		maxVendorID = largestID
+	} else if largestID > maxVendorID {
+		return nil, 0, fmt.Errorf("%s holds vendor %d, but the max vendor ID is %d", field, largestID, maxVendorID)
+	}
+	return ranges, maxVendorID, nil
+}
+
+// writeVendorSection writes the MaxVendorId, the IsRangeEncoding flag and then the vendor ids,
+// either as a BitField or as a RangeSection. If maxVendorID is 0, the largest of the vendor ids is used instead.
+func writeVendorSection(w *bitutils.BitWriter, field string, vendorIDs []uint16, maxVendorID uint16, encoding VendorEncoding) error {
+	ranges, maxVendorID, err := vendorRanges(field, vendorIDs, maxVendorID)
+	if err != nil {
+		return err
+	}
+
+	var isRangeEncoding bool
+	switch encoding {
+	case ShortestEncoding:
+		sizes := sectionSizes(ranges, maxVendorID)
+		isRangeEncoding = sizes.RangeSection < sizes.BitField
+	case BitFieldEncoding:
+		isRangeEncoding = false
+	case RangeEncoding:
+		isRangeEncoding = true
+	default:
+		return fmt.Errorf("VendorEncoding %d is undefined", encoding)
+	}
+
+	w.WriteBits(uint64(maxVendorID), 16)
+	w.WriteBool(isRangeEncoding)
+	if isRangeEncoding {
+		if err := writeRangeEntries(w, ranges, maxVendorID); err != nil {
+			return fmt.Errorf("%s can't be encoded in a RangeSection: %s", field, err.Error())
+		}
+		return nil
+	}
+	writeBitField(w, ranges, maxVendorID)
+	return nil
+}
+
+func writeBitField(w *bitutils.BitWriter, ranges []VendorRange, maxVendorID uint16) {
+	vendors := make([]bool, maxVendorID)
+	for _, vendorRange := range ranges {
+		for id := int(vendorRange.StartID); id <= int(vendorRange.EndID); id++ {
+			vendors[id-1] = true
+		}
+	}
+	for _, vendor := range vendors {
+		w.WriteBool(vendor)
+	}
+}
+
+func writePubRestrictions(w *bitutils.BitWriter, restrictions []PubRestriction) error {
+	if err := bitutils.CheckMaxValue("the number of PubRestrictions", uint64(len(restrictions)), 12); err != nil {
+		return err
+	}
+
+	// Sort a copy, so that the same restrictions always produce the same string
+	sorted := make([]PubRestriction, len(restrictions))
+	copy(sorted, restrictions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].PurposeID != sorted[j].PurposeID {
+			return sorted[i].PurposeID < sorted[j].PurposeID
+		}
+		return sorted[i].RestrictType < sorted[j].RestrictType
+	})
+
+	w.WriteBits(uint64(len(sorted)), 12)
+	for i, restriction := range sorted {
+		if restriction.PurposeID < 1 || restriction.PurposeID > 24 {
+			return fmt.Errorf("publisher restriction on purpose %d is invalid, only purposes [1, 24] are valid", restriction.PurposeID)
+		}
+		if restriction.RestrictType > PubRestrictRequireLI {
+			return fmt.Errorf("publisher restriction on purpose %d has type %d, which is undefined", restriction.PurposeID, restriction.RestrictType)
+		}
+		if i > 0 && sorted[i-1].PurposeID == restriction.PurposeID && sorted[i-1].RestrictType == restriction.RestrictType {
+			return fmt.Errorf("publisher restriction type %d on purpose %d is set more than once", restriction.RestrictType, restriction.PurposeID)
+		}
+		w.WriteBits(uint64(restriction.PurposeID), 6)
+		w.WriteBits(uint64(restriction.RestrictType), 2)
+		// The parser reads restriction ranges with assumedMaxVendorID, so larger IDs wouldn't round-trip
+		if err := writeRangeEntries(w, restriction.Vendors, assumedMaxVendorID); err != nil {
+			return fmt.Errorf("publisher restriction type %d on purpose %d is invalid: %s", restriction.RestrictType, restriction.PurposeID, err.Error())
+		}
+	}
+	return nil
+}
+
+// writeRangeEntries writes the NumEntries followed by one single or range entry for each range.
+// Every range must be within [1, maxVendorID].
+func writeRangeEntries(w *bitutils.BitWriter, ranges []VendorRange, maxVendorID uint16) error {
+	if err := bitutils.CheckMaxValue("the number of vendor ranges", uint64(len(ranges)), 12); err != nil {
+		return err
+	}
+	w.WriteBits(uint64(len(ranges)), 12)
+	for _, vendors := range ranges {
+		if vendors.StartID == 0 {
+			return fmt.Errorf("vendor range [%d, %d] starts at 0, but the min vendor ID is 1", vendors.StartID, vendors.EndID)
+		}
+		if vendors.EndID < vendors.StartID {
+			return fmt.Errorf("vendor range [%d, %d] ends before it starts", vendors.StartID, vendors.EndID)
+		}
+		if vendors.EndID > maxVendorID {
+			return fmt.Errorf("vendor range [%d, %d] ends after the max vendor ID %d", vendors.StartID, vendors.EndID, maxVendorID)
+		}
+		if vendors.StartID == vendors.EndID {
+			w.WriteBool(false)
+			w.WriteBits(uint64(vendors.StartID), 16)
+		} else {
+			w.WriteBool(true)
+			w.WriteBits(uint64(vendors.StartID), 16)
+			w.WriteBits(uint64(vendors.EndID), 16)
+		}
+	}
+	return nil
+}
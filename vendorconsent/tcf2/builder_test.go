@@ -0,0 +1,399 @@
+package vendorconsent
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+func TestConsentBuilderRoundTrip(t *testing.T) {
+	created := time.Date(2020, time.February, 27, 19, 51, 49, 300000000, time.UTC)
+	lastUpdated := time.Date(2023, time.May, 18, 16, 7, 14, 0, time.UTC)
+	builder := ConsentBuilder{
+		Created:                   created,
+		LastUpdated:               lastUpdated,
+		CmpID:                     923,
+		CmpVersion:                776,
+		ConsentScreen:             56,
+		ConsentLanguage:           "sv",
+		VendorListVersion:         48,
+		TCFPolicyVersion:          4,
+		IsServiceSpecific:         true,
+		UseNonStandardTexts:       false,
+		SpecialFeatureOptIns:      []consentconstants.SpecialFeature{2, 12},
+		PurposesConsent:           []consentconstants.Purpose{1, 3, 4, 24, 3},
+		PurposesLITransparency:    []consentconstants.Purpose{2, 7},
+		PurposeOneTreatment:       true,
+		PublisherCC:               "DE",
+		VendorConsents:            []uint16{626, 23, 42, 126, 127, 128},
+		VendorLegitimateInterests: []uint16{24, 44},
+		VendorLegitInterestMaxID:  50,
+		PubRestrictions: []PubRestriction{
+			{PurposeID: 7, RestrictType: PubRestrictRequireLI, Vendors: []VendorRange{{StartID: 32, EndID: 32}}},
+			{PurposeID: 2, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 1, EndID: 40}, {StartID: 700, EndID: 700}}},
+		},
+	}
+
+	encoded, err := builder.EncodeString()
+	assertNilError(t, err)
+	consent, err := ParseString(encoded)
+	assertNilError(t, err)
+
+	assertUInt8sEqual(t, 2, consent.Version())
+	if !consent.Created().Equal(created) {
+		t.Errorf("Created dates were not equal. Expected %s, actual %s", created, consent.Created())
+	}
+	if !consent.LastUpdated().Equal(lastUpdated) {
+		t.Errorf("LastUpdated dates were not equal. Expected %s, actual %s", lastUpdated, consent.LastUpdated())
+	}
+	assertUInt16sEqual(t, 923, consent.CmpID())
+	assertUInt16sEqual(t, 776, consent.CmpVersion())
+	assertUInt8sEqual(t, 56, consent.ConsentScreen())
+	assertStringsEqual(t, "SV", consent.ConsentLanguage())
+	assertUInt16sEqual(t, 48, consent.VendorListVersion())
+	assertUInt8sEqual(t, 4, consent.TCFPolicyVersion())
+	assertBoolsEqual(t, true, consent.IsServiceSpecific())
+	assertBoolsEqual(t, false, consent.UseNonStandardTexts())
+	assertBoolsEqual(t, true, consent.PurposeOneTreatment())
+	assertStringsEqual(t, "DE", consent.PublisherCC())
+
+	specialFeatures := buildMap(2, 12)
+	for i := uint16(1); i <= 12; i++ {
+		_, ok := specialFeatures[uint(i)]
+		assertBoolsEqual(t, ok, consent.SpecialFeatureOptIn(i))
+	}
+	purposesConsent := buildMap(1, 3, 4, 24)
+	purposesLITransparency := buildMap(2, 7)
+	for i := uint8(1); i <= 24; i++ {
+		_, ok := purposesConsent[uint(i)]
+		assertBoolsEqual(t, ok, consent.PurposeAllowed(consentconstants.Purpose(i)))
+		_, ok = purposesLITransparency[uint(i)]
+		assertBoolsEqual(t, ok, consent.PurposeLITransparency(consentconstants.Purpose(i)))
+	}
+
+	assertUInt16sEqual(t, 626, consent.MaxVendorID())
+	assertUInt16SlicesEqual(t, []uint16{23, 42, 126, 127, 128, 626}, consent.VendorConsentIDs())
+	assertUInt16sEqual(t, 50, consent.VendorLegitInterestMaxID())
+	assertUInt16SlicesEqual(t, []uint16{24, 44}, consent.VendorLegitInterestIDs())
+
+	expectedRestrictions := []PubRestriction{
+		{PurposeID: 2, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 1, EndID: 40}, {StartID: 700, EndID: 700}}},
+		{PurposeID: 7, RestrictType: PubRestrictRequireLI, Vendors: []VendorRange{{StartID: 32, EndID: 32}}},
+	}
+	if actual := consent.PubRestrictions(); !reflect.DeepEqual(expectedRestrictions, actual) {
+		t.Errorf("Publisher restrictions were not equal. Expected %+v, actual %+v", expectedRestrictions, actual)
+	}
+}
+
+func TestConsentBuilderRestrictionMaxVendorID(t *testing.T) {
+	builder := ConsentBuilder{
+		ConsentLanguage:   "EN",
+		VendorListVersion: 1,
+		PublisherCC:       "FR",
+		PubRestrictions: []PubRestriction{
+			{PurposeID: 2, Vendors: []VendorRange{{StartID: 32000, EndID: assumedMaxVendorID}}},
+		},
+	}
+	encoded, err := builder.EncodeString()
+	assertNilError(t, err)
+	consent, err := ParseString(encoded)
+	assertNilError(t, err)
+	assertBoolsEqual(t, true, consent.CheckPubRestriction(2, uint8(PubRestrictNotAllowed), assumedMaxVendorID))
+}
+
+func TestConsentBuilderReproducesStrings(t *testing.T) {
+	tests := []string{
+		"COwGVJOOwGVJOADACHENAOCAAO6as_-AAAhoAFNLAAoAAAA",
+		"CPtGDMAPtGDMALMAAAENA_C_AAAAAAAAACiQAAAAAAAA",
+		"COwAdDhOwAdDhN4ABAENAPCgAAQAAv___wAAAFP_AAp_4AI6ACACAA",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			consent, err := ParseString(test)
+			assertNilError(t, err)
+			encoded, err := builderFromConsent(consent).EncodeString()
+			assertNilError(t, err)
+			assertStringsEqual(t, test, encoded)
+		})
+	}
+}
+
+func TestConsentBuilderEmptyVendors(t *testing.T) {
+	builder := ConsentBuilder{
+		ConsentLanguage:   "EN",
+		VendorListVersion: 1,
+		PublisherCC:       "AA",
+	}
+	data, err := builder.Encode()
+	assertNilError(t, err)
+	consent, err := Parse(data)
+	assertNilError(t, err)
+	assertUInt16sEqual(t, 0, consent.MaxVendorID())
+	assertUInt16sEqual(t, 0, consent.VendorLegitInterestMaxID())
+	assertIntsEqual(t, 0, len(consent.PubRestrictions()))
+	if created := consent.Created(); created.Unix() != 0 {
+		t.Errorf("A zero Created date should be encoded as the Unix epoch. Got %s", created)
+	}
+}
+
+func TestConsentBuilderErrors(t *testing.T) {
+	valid := ConsentBuilder{
+		ConsentLanguage:   "EN",
+		VendorListVersion: 1,
+		PublisherCC:       "US",
+	}
+	tests := []struct {
+		name        string
+		modify      func(b *ConsentBuilder)
+		expectError string
+	}{
+		{
+			name:        "vendor_list_version_0",
+			modify:      func(b *ConsentBuilder) { b.VendorListVersion = 0 },
+			expectError: "the consent string encoded a VendorListVersion of 0, but this value must be greater than or equal to 1",
+		},
+		{
+			name:        "cmp_id_too_large",
+			modify:      func(b *ConsentBuilder) { b.CmpID = 4096 },
+			expectError: "CmpID is 4096, but the consent string only has room for values up to 4095",
+		},
+		{
+			name:        "created_before_epoch",
+			modify:      func(b *ConsentBuilder) { b.Created = time.Date(1969, time.January, 1, 0, 0, 0, 0, time.UTC) },
+			expectError: "Created is 1969-01-01 00:00:00 +0000 UTC, which can't be stored in a consent string",
+		},
+		{
+			name:        "language_too_long",
+			modify:      func(b *ConsentBuilder) { b.ConsentLanguage = "ENG" },
+			expectError: `ConsentLanguage must be two letters long. Got "ENG"`,
+		},
+		{
+			name:        "publisher_cc_not_letters",
+			modify:      func(b *ConsentBuilder) { b.PublisherCC = "U1" },
+			expectError: `PublisherCC must only contain the letters A to Z. Got "U1"`,
+		},
+		{
+			name:        "purpose_25",
+			modify:      func(b *ConsentBuilder) { b.PurposesConsent = []consentconstants.Purpose{1, 25} },
+			expectError: "PurposesConsent holds 25, but only values in [1, 24] are valid",
+		},
+		{
+			name:        "special_feature_0",
+			modify:      func(b *ConsentBuilder) { b.SpecialFeatureOptIns = []consentconstants.SpecialFeature{0} },
+			expectError: "SpecialFeatureOptIns holds 0, but only values in [1, 12] are valid",
+		},
+		{
+			name:        "vendor_0",
+			modify:      func(b *ConsentBuilder) { b.VendorLegitimateInterests = []uint16{3, 0} },
+			expectError: "VendorLegitimateInterests holds vendor 0, but the min vendor ID is 1",
+		},
+		{
+			name: "vendor_above_max_vendor_id",
+			modify: func(b *ConsentBuilder) {
+				b.VendorConsents = []uint16{3, 11}
+				b.MaxVendorID = 10
+			},
+			expectError: "VendorConsents holds vendor 11, but the max vendor ID is 10",
+		},
+		{
+			name: "range_encoded_allowed_vendor_above_max",
+			modify: func(b *ConsentBuilder) {
+				b.AllowedVendors = []uint16{40}
+				b.AllowedVendorMaxID = 39
+				b.VendorEncoding = RangeEncoding
+			},
+			expectError: "AllowedVendors holds vendor 40, but the max vendor ID is 39",
+		},
+		{
+			name: "too_many_custom_purposes",
+			modify: func(b *ConsentBuilder) {
+				b.PublisherTC = &PublisherTCBuilder{CustomPurposesLITransparency: []uint8{64}}
+			},
+			expectError: "NumCustomPurposes is 64, but the consent string only has room for values up to 63",
+		},
+		{
+			name: "custom_purpose_above_num_custom_purposes",
+			modify: func(b *ConsentBuilder) {
+				b.PublisherTC = &PublisherTCBuilder{NumCustomPurposes: 2, CustomPurposesConsent: []uint8{3}}
+			},
+			expectError: "PublisherTC.CustomPurposesConsent holds 3, but only values in [1, 2] are valid",
+		},
+		{
+			name:        "undefined_vendor_encoding",
+			modify:      func(b *ConsentBuilder) { b.VendorEncoding = 3 },
+			expectError: "VendorEncoding 3 is undefined",
+		},
+		{
+			name: "duplicate_restriction",
+			modify: func(b *ConsentBuilder) {
+				b.PubRestrictions = []PubRestriction{
+					{PurposeID: 2, RestrictType: PubRestrictRequireConsent, Vendors: []VendorRange{{StartID: 1, EndID: 1}}},
+					{PurposeID: 2, RestrictType: PubRestrictRequireConsent, Vendors: []VendorRange{{StartID: 5, EndID: 6}}},
+				}
+			},
+			expectError: "publisher restriction type 1 on purpose 2 is set more than once",
+		},
+		{
+			name: "undefined_restriction_type",
+			modify: func(b *ConsentBuilder) {
+				b.PubRestrictions = []PubRestriction{{PurposeID: 2, RestrictType: 3}}
+			},
+			expectError: "publisher restriction on purpose 2 has type 3, which is undefined",
+		},
+		{
+			name: "restriction_range_backwards",
+			modify: func(b *ConsentBuilder) {
+				b.PubRestrictions = []PubRestriction{{PurposeID: 2, Vendors: []VendorRange{{StartID: 5, EndID: 4}}}}
+			},
+			expectError: "publisher restriction type 0 on purpose 2 is invalid: vendor range [5, 4] ends before it starts",
+		},
+		{
+			name: "restriction_vendor_unreadable",
+			modify: func(b *ConsentBuilder) {
+				b.PubRestrictions = []PubRestriction{{PurposeID: 2, Vendors: []VendorRange{{StartID: 40000, EndID: 40000}}}}
+			},
+			expectError: "publisher restriction type 0 on purpose 2 is invalid: vendor range [40000, 40000] ends after the max vendor ID 32767",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := valid
+			tt.modify(&builder)
+			_, err := builder.EncodeString()
+			assertError(t, err)
+			assertStringsEqual(t, tt.expectError, err.Error())
+		})
+	}
+}
+
+func TestEncodedSizes(t *testing.T) {
+	tests := []struct {
+		name        string
+		vendorIDs   []uint16
+		maxVendorID uint16
+		expected    VendorSectionSizes
+	}{
+		{
+			name:     "empty",
+			expected: VendorSectionSizes{BitField: 0, RangeSection: 12},
+		},
+		{
+			name:      "single_and_range_entries",
+			vendorIDs: []uint16{300, 2, 3, 4, 3, 10},
+			expected:  VendorSectionSizes{BitField: 300, RangeSection: 12 + 33 + 17 + 17},
+		},
+		{
+			name:        "max_vendor_id",
+			vendorIDs:   []uint16{1, 2, 3},
+			maxVendorID: 20,
+			expected:    VendorSectionSizes{BitField: 20, RangeSection: 12 + 33},
+		},
+		{
+			name:      "last_vendor_id",
+			vendorIDs: []uint16{65535, 65534, 65535},
+			expected:  VendorSectionSizes{BitField: 65535, RangeSection: 12 + 33},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizes, err := EncodedSizes(tt.vendorIDs, tt.maxVendorID)
+			assertNilError(t, err)
+			if sizes != tt.expected {
+				t.Errorf("Sizes were not equal. Expected %+v, actual %+v", tt.expected, sizes)
+			}
+		})
+	}
+
+	_, err := EncodedSizes([]uint16{5}, 4)
+	assertError(t, err)
+}
+
+func TestConsentBuilderVendorEncoding(t *testing.T) {
+	sparse := []uint16{2, 900}
+	dense := []uint16{1, 3, 4, 6, 7, 9, 10, 12}
+	tests := []struct {
+		name        string
+		encoding    VendorEncoding
+		vendorIDs   []uint16
+		expectRange bool
+	}{
+		{name: "shortest_sparse", encoding: ShortestEncoding, vendorIDs: sparse, expectRange: true},
+		{name: "shortest_dense", encoding: ShortestEncoding, vendorIDs: dense, expectRange: false},
+		{name: "forced_bitfield", encoding: BitFieldEncoding, vendorIDs: sparse, expectRange: false},
+		{name: "forced_range", encoding: RangeEncoding, vendorIDs: dense, expectRange: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := ConsentBuilder{
+				ConsentLanguage:           "EN",
+				VendorListVersion:         1,
+				PublisherCC:               "US",
+				VendorConsents:            tt.vendorIDs,
+				VendorLegitimateInterests: tt.vendorIDs,
+				DisclosedVendors:          tt.vendorIDs,
+				AllowedVendors:            tt.vendorIDs,
+				VendorEncoding:            tt.encoding,
+			}
+			encoded, err := builder.EncodeString()
+			assertNilError(t, err)
+			consent, err := ParseString(encoded)
+			assertNilError(t, err)
+			metadata := consent.(ConsentMetadata)
+
+			sections := map[string]vendorConsentsResolver{
+				"VendorConsents":            metadata.vendorConsents,
+				"VendorLegitimateInterests": metadata.vendorLegitimateInterests,
+				"DisclosedVendors":          metadata.disclosedVendors,
+				"AllowedVendors":            metadata.allowedVendors,
+			}
+			for field, section := range sections {
+				_, isRange := section.(*rangeSection)
+				if isRange != tt.expectRange {
+					t.Errorf("%s: expected a RangeSection: %t, actual %T", field, tt.expectRange, section)
+				}
+				assertUInt16SlicesEqual(t, sortedCopy(tt.vendorIDs), section.VendorIDs())
+			}
+		})
+	}
+}
+
+func TestConsentBuilderSegments(t *testing.T) {
+	builder := ConsentBuilder{
+		ConsentLanguage:      "EN",
+		VendorListVersion:    1,
+		PublisherCC:          "US",
+		DisclosedVendors:     []uint16{},
+		DisclosedVendorMaxID: 0,
+		AllowedVendors:       []uint16{12, 4},
+		AllowedVendorMaxID:   30,
+	}
+	encoded, err := builder.EncodeString()
+	assertNilError(t, err)
+	consent, err := ParseString(encoded)
+	assertNilError(t, err)
+	assertBoolsEqual(t, true, consent.HasDisclosedVendorsSegment())
+	assertUInt16sEqual(t, 0, consent.DisclosedVendorMaxID())
+	assertBoolsEqual(t, true, consent.HasAllowedVendorsSegment())
+	assertUInt16sEqual(t, 30, consent.AllowedVendorMaxID())
+	assertBoolsEqual(t, true, consent.VendorAllowedOOB(4))
+	assertBoolsEqual(t, false, consent.VendorAllowedOOB(5))
+
+	builder.DisclosedVendors = nil
+	builder.AllowedVendors = nil
+	encoded, err = builder.EncodeString()
+	assertNilError(t, err)
+	consent, err = ParseString(encoded)
+	assertNilError(t, err)
+	assertBoolsEqual(t, false, consent.HasDisclosedVendorsSegment())
+	assertBoolsEqual(t, false, consent.HasAllowedVendorsSegment())
+}
+
+func sortedCopy(ids []uint16) []uint16 {
+	sorted := make([]uint16, len(ids))
+	copy(sorted, ids)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
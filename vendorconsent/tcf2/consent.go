@@ -15,34 +15,133 @@ const (
 	consentStringTCF2Prefix    = 'C'
 )
 
-// ParseString parses the TCF 2.0 vendor string base64 encoded
-func ParseString(consent string) (api.VendorConsents, error) {
+// VendorConsents is a TCF 2.0 consent string. It extends api.VendorConsents with the fields
+// which only exist in TCF 2.0, so that callers don't need to type-assert to ConsentMetadata.
+type VendorConsents interface {
+	api.VendorConsents
+
+	// IsServiceSpecific returns true if the consent string only applies to the service which created it.
+	IsServiceSpecific() bool
+
+	// UseNonStandardTexts returns true if the CMP used non-standard texts or stacks when asking for consent.
+	UseNonStandardTexts() bool
+
+	// SpecialFeatureOptIn returns true if the user opted in to the given special feature.
+	SpecialFeatureOptIn(id uint16) bool
+
+	// PurposeLITransparency returns true if legitimate interest was disclosed to the user for the given purpose.
+	PurposeLITransparency(id consentconstants.Purpose) bool
+
+	// PurposeOneTreatment returns true if Purpose 1 was not disclosed to the user, which is
+	// only allowed in the country returned by PublisherCC.
+	PurposeOneTreatment() bool
+
+	// PublisherCC returns the two-letter ISO 3166-1 alpha-2 country code of the publisher, in uppercase.
+	PublisherCC() string
+
+	// VendorLegitInterestMaxID is the upper bound (inclusive) on valid inputs for VendorLegitInterest(id).
+	VendorLegitInterestMaxID() uint16
+
+	// VendorLegitInterest returns true if the user didn't object to the vendor's legitimate interest.
+	VendorLegitInterest(id uint16) bool
+
+	// VendorConsentIDs returns the IDs of every vendor with consent, in ascending order.
+	// This is cheaper than calling VendorConsent for every ID up to MaxVendorID.
+	VendorConsentIDs() []uint16
+
+	// VendorLegitInterestIDs returns the IDs of every vendor with legitimate interest established, in ascending order.
+	VendorLegitInterestIDs() []uint16
+
+	// CheckPubRestriction returns true if the publisher set the given restriction type on the purpose for the vendor.
+	CheckPubRestriction(purposeID uint8, restrictType uint8, vendor uint16) bool
+
+	// PubRestrictions lists every publisher restriction, sorted by purpose id and restriction type.
+	PubRestrictions() []PubRestriction
+
+	// VendorPubRestrictions maps every purpose id which the publisher restricted for the vendor to the restriction type.
+	VendorPubRestrictions(vendor uint16) map[uint8]uint8
+
+	// HasDisclosedVendorsSegment returns true if the consent string included the Disclosed Vendors segment.
+	HasDisclosedVendorsSegment() bool
+
+	// DisclosedVendorMaxID is the upper bound (inclusive) on valid inputs for VendorDisclosed(id).
+	DisclosedVendorMaxID() uint16
+
+	// VendorDisclosed returns true if the CMP disclosed the vendor to the user.
+	VendorDisclosed(id uint16) bool
+
+	// HasAllowedVendorsSegment returns true if the consent string included the Allowed Vendors segment.
+	HasAllowedVendorsSegment() bool
+
+	// AllowedVendorMaxID is the upper bound (inclusive) on valid inputs for VendorAllowedOOB(id).
+	AllowedVendorMaxID() uint16
+
+	// VendorAllowedOOB returns true if the publisher allows the vendor to use out-of-band legal bases.
+	VendorAllowedOOB(id uint16) bool
+
+	// PublisherTC returns the Publisher TC segment, or nil if the consent string didn't include it.
+	PublisherTC() PublisherTC
+
+	// VendorPurposeLegalBasis returns the legal basis which the vendor established for the purpose, once the
+	// publisher restrictions and the legitimate interest rules of the TCFPolicyVersion apply, or LegalBasisNone
+	// if the vendor may not process personal data for it.
+	VendorPurposeLegalBasis(vendorID uint16, vendor api.Vendor, purpose consentconstants.Purpose) consentconstants.LegalBasis
+}
+
+// ParseString parses the TCF 2.0 vendor string base64 encoded, including the optional segments
+// which follow the core string.
+func ParseString(consent string) (VendorConsents, error) {
 	if consent == "" {
 		return nil, consentconstants.ErrEmptyDecodedConsent
 	}
 	// split TCF 2.0 segments
-	if index := strings.IndexByte(consent, consentStringTCF2Separator); index != -1 {
-		consent = consent[:index]
+	segments := strings.Split(consent, string(consentStringTCF2Separator))
+
+	decoded, err := decodeSegment(segments[0])
+	if err != nil {
+		return nil, err
 	}
 
-	buff := []byte(consent)
-	decoded := buff
-	n, err := base64.RawURLEncoding.Decode(decoded, buff)
+	metadata, err := parseCore(decoded)
 	if err != nil {
 		return nil, err
 	}
-	decoded = decoded[:n:n]
 
-	return Parse(decoded)
+	if err := parseSegments(&metadata, segments[1:]); err != nil {
+		return nil, err
+	}
+	metadata.encodedSegments = segments
+
+	return metadata, nil
 }
 
 // Parse parses the TCF 2.0 vendor consent data from the string. This string should *not* be encoded (by base64 or any other encoding).
 // If the data is malformed and cannot be interpreted as a vendor consent string, this will return an error.
-func Parse(data []byte) (api.VendorConsents, error) {
-	metadata, err := parseMetadata(data)
+//
+// The data must only contain the core segment. Use ParseString to read the optional segments.
+func Parse(data []byte) (VendorConsents, error) {
+	metadata, err := parseCore(data)
+	if err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	buff := []byte(segment)
+	decoded := buff
+	n, err := base64.RawURLEncoding.Decode(decoded, buff)
 	if err != nil {
 		return nil, err
 	}
+	return decoded[:n:n], nil
+}
+
+func parseCore(data []byte) (ConsentMetadata, error) {
+	metadata, err := parseMetadata(data)
+	if err != nil {
+		return ConsentMetadata{}, err
+	}
 
 	var vendorConsents vendorConsentsResolver
 	var vendorLegitInts vendorConsentsResolver
@@ -52,31 +151,34 @@ func Parse(data []byte) (api.VendorConsents, error) {
 	// Bit 229 determines whether or not the consent string encodes Vendor data in a RangeSection or BitField.
 	// We know from parseMetadata that we have at least 29*8=232 bits available
 	if isSet(data, 229) {
-		vendorConsents, legitIntStart, err = parseRangeSection(metadata, metadata.MaxVendorID(), 230)
+		if len(data) < 31 {
+			return ConsentMetadata{}, fmt.Errorf("vendor consent strings using RangeSections require at least 31 bytes. Got %d", len(data))
+		}
+		vendorConsents, legitIntStart, err = parseRangeSection(data, metadata.MaxVendorID(), 230)
 	} else {
-		vendorConsents, legitIntStart, err = parseBitField(metadata, metadata.MaxVendorID(), 230)
+		vendorConsents, legitIntStart, err = parseBitField(data, metadata.MaxVendorID(), 230)
 	}
 	if err != nil {
-		return nil, err
+		return ConsentMetadata{}, err
 	}
 
 	metadata.vendorConsents = vendorConsents
 	metadata.vendorLegitimateInterestStart = legitIntStart + 17
 	legIntMaxVend, err := bitutils.ParseUInt16(data, legitIntStart)
 	if err != nil {
-		return nil, err
+		return ConsentMetadata{}, err
 	}
 
 	if legitIntStart+16 >= uint(len(data))*8 {
-		return nil, fmt.Errorf("invalid consent data: no legitimate interest start position")
+		return ConsentMetadata{}, fmt.Errorf("invalid consent data: no legitimate interest start position")
 	}
 	if isSet(data, legitIntStart+16) {
-		vendorLegitInts, pubRestrictsStart, err = parseRangeSection(metadata, legIntMaxVend, metadata.vendorLegitimateInterestStart)
+		vendorLegitInts, pubRestrictsStart, err = parseRangeSection(data, legIntMaxVend, metadata.vendorLegitimateInterestStart)
 	} else {
-		vendorLegitInts, pubRestrictsStart, err = parseBitField(metadata, legIntMaxVend, metadata.vendorLegitimateInterestStart)
+		vendorLegitInts, pubRestrictsStart, err = parseBitField(data, legIntMaxVend, metadata.vendorLegitimateInterestStart)
 	}
 	if err != nil {
-		return nil, err
+		return ConsentMetadata{}, err
 	}
 
 	metadata.vendorLegitimateInterests = vendorLegitInts
@@ -84,7 +186,7 @@ func Parse(data []byte) (api.VendorConsents, error) {
 
 	pubRestrictions, _, err := parsePubRestriction(metadata, pubRestrictsStart)
 	if err != nil {
-		return nil, err
+		return ConsentMetadata{}, err
 	}
 
 	metadata.publisherRestrictions = pubRestrictions
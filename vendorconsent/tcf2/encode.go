@@ -0,0 +1,140 @@
+package vendorconsent
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+// EncodeLossless encodes a consent string returned by ParseString or Parse back into its base64 form.
+// Consent strings returned by ParseString come back exactly as they were passed, including unknown segments,
+// trailing bits and the pad bits of the last character of every segment.
+func EncodeLossless(consent VendorConsents) (string, error) {
+	metadata, ok := consent.(ConsentMetadata)
+	if !ok {
+		return "", errors.New("lossless encoding needs a consent string returned by ParseString or Parse")
+	}
+	if metadata.encodedSegments == nil {
+		return base64.RawURLEncoding.EncodeToString(metadata.data), nil
+	}
+	return strings.Join(metadata.encodedSegments, string(consentStringTCF2Separator)), nil
+}
+
+// EncodeCanonical encodes the values of the consent string from scratch. Consent strings which hold the same values
+// always produce the same output: every list of vendors uses its shortest encoding, the publisher restrictions
+// and the optional segments are sorted, and trailing bits, unknown segments and publisher restrictions of the
+// reserved type 3 are dropped. The ConsentLanguage and PublisherCC keep their 6-bit values, even if they aren't letters.
+func EncodeCanonical(consent VendorConsents) (string, error) {
+	return builderFromConsent(consent).EncodeString()
+}
+
+// builderFromConsent copies every value of the consent string into a ConsentBuilder
+func builderFromConsent(consent VendorConsents) ConsentBuilder {
+	builder := ConsentBuilder{
+		Created:                   consent.Created(),
+		LastUpdated:               consent.LastUpdated(),
+		CmpID:                     consent.CmpID(),
+		CmpVersion:                consent.CmpVersion(),
+		ConsentScreen:             consent.ConsentScreen(),
+		ConsentLanguage:           consent.ConsentLanguage(),
+		VendorListVersion:         consent.VendorListVersion(),
+		TCFPolicyVersion:          consent.TCFPolicyVersion(),
+		IsServiceSpecific:         consent.IsServiceSpecific(),
+		UseNonStandardTexts:       consent.UseNonStandardTexts(),
+		PurposeOneTreatment:       consent.PurposeOneTreatment(),
+		PublisherCC:               consent.PublisherCC(),
+		VendorConsents:            consent.VendorConsentIDs(),
+		MaxVendorID:               consent.MaxVendorID(),
+		VendorLegitimateInterests: consent.VendorLegitInterestIDs(),
+		VendorLegitInterestMaxID:  consent.VendorLegitInterestMaxID(),
+		rawLetters:                true,
+	}
+	for _, restriction := range consent.PubRestrictions() {
+		// Type 3 is reserved by the TCF, so it has no meaning to keep
+		if restriction.RestrictType > PubRestrictRequireLI {
+			continue
+		}
+		restriction.Vendors = mergeRanges(restriction.Vendors)
+		builder.PubRestrictions = append(builder.PubRestrictions, restriction)
+	}
+	for i := uint16(1); i <= 12; i++ {
+		if consent.SpecialFeatureOptIn(i) {
+			builder.SpecialFeatureOptIns = append(builder.SpecialFeatureOptIns, consentconstants.SpecialFeature(i))
+		}
+	}
+	for i := consentconstants.Purpose(1); i <= 24; i++ {
+		if consent.PurposeAllowed(i) {
+			builder.PurposesConsent = append(builder.PurposesConsent, i)
+		}
+		if consent.PurposeLITransparency(i) {
+			builder.PurposesLITransparency = append(builder.PurposesLITransparency, i)
+		}
+	}
+
+	if consent.HasDisclosedVendorsSegment() {
+		// Keep the list non-nil even if no vendor is disclosed, so that the segment is still encoded
+		builder.DisclosedVendors = []uint16{}
+		builder.DisclosedVendorMaxID = consent.DisclosedVendorMaxID()
+		// i wraps around to 0 after the last vendor ID, 65535
+		for i := uint16(1); i <= builder.DisclosedVendorMaxID && i != 0; i++ {
+			if consent.VendorDisclosed(i) {
+				builder.DisclosedVendors = append(builder.DisclosedVendors, i)
+			}
+		}
+	}
+	if consent.HasAllowedVendorsSegment() {
+		builder.AllowedVendors = []uint16{}
+		builder.AllowedVendorMaxID = consent.AllowedVendorMaxID()
+		for i := uint16(1); i <= builder.AllowedVendorMaxID && i != 0; i++ {
+			if consent.VendorAllowedOOB(i) {
+				builder.AllowedVendors = append(builder.AllowedVendors, i)
+			}
+		}
+	}
+	if publisherTC := consent.PublisherTC(); publisherTC != nil {
+		builder.PublisherTC = &PublisherTCBuilder{NumCustomPurposes: publisherTC.NumCustomPurposes()}
+		for i := consentconstants.Purpose(1); i <= 24; i++ {
+			if publisherTC.PurposeConsent(i) {
+				builder.PublisherTC.PurposesConsent = append(builder.PublisherTC.PurposesConsent, i)
+			}
+			if publisherTC.PurposeLITransparency(i) {
+				builder.PublisherTC.PurposesLITransparency = append(builder.PublisherTC.PurposesLITransparency, i)
+			}
+		}
+		for i := uint8(1); i <= publisherTC.NumCustomPurposes(); i++ {
+			if publisherTC.CustomPurposeConsent(i) {
+				builder.PublisherTC.CustomPurposesConsent = append(builder.PublisherTC.CustomPurposesConsent, i)
+			}
+			if publisherTC.CustomPurposeLITransparency(i) {
+				builder.PublisherTC.CustomPurposesLITransparency = append(builder.PublisherTC.CustomPurposesLITransparency, i)
+			}
+		}
+	}
+	return builder
+}
+
+// mergeRanges sorts the vendor ranges, and merges the ones which overlap or touch
+func mergeRanges(ranges []VendorRange) []VendorRange {
+	sorted := make([]VendorRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].StartID < sorted[j].StartID })
+
+	merged := make([]VendorRange, 0, len(sorted))
+	for _, vendors := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && merged[last].EndID != 65535 && vendors.StartID <= merged[last].EndID+1 {
+			if vendors.EndID > merged[last].EndID {
+				merged[last].EndID = vendors.EndID
+			}
+			continue
+		}
+		if last >= 0 && merged[last].EndID == 65535 {
+			continue
+		}
+		merged = append(merged, vendors)
+	}
+	return merged
+}
@@ -0,0 +1,179 @@
+package vendorconsent
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+func TestEncodeLossless(t *testing.T) {
+	tests := []string{
+		coreString,
+		"COwGVJOOwGVJOADACHENAOCAAO6as_-AAAhoAFNLAAoAAAA",
+		// Followed by an Allowed Vendors segment, a Disclosed Vendors segment and an unknown segment type
+		coreString + ".QAEkCA.ICWQAoABAAIASwA.4A",
+		// With trailing bits at the end of the core string
+		coreString + "AAAAAA",
+		// With non-zero pad bits in the last character of the core string and of a segment
+		"COwGVJOOwGVJOADACHENAOCAAO6as_-AAAhoAFNLAAoAAAB",
+		coreString + ".QAEkCB",
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			consent, err := ParseString(test)
+			assertNilError(t, err)
+			encoded, err := EncodeLossless(consent)
+			assertNilError(t, err)
+			assertStringsEqual(t, test, encoded)
+		})
+	}
+
+	// Parse only has the bytes of the core string, which are encoded again
+	data, err := base64.RawURLEncoding.DecodeString(coreString)
+	assertNilError(t, err)
+	consent, err := Parse(data)
+	assertNilError(t, err)
+	encoded, err := EncodeLossless(consent)
+	assertNilError(t, err)
+	assertStringsEqual(t, coreString, encoded)
+}
+
+func TestEncodeCanonical(t *testing.T) {
+	builder := ConsentBuilder{
+		ConsentLanguage:           "EN",
+		VendorListVersion:         12,
+		PublisherCC:               "FR",
+		VendorConsents:            []uint16{3, 700},
+		VendorLegitimateInterests: []uint16{1, 2, 3, 5, 8},
+		PubRestrictions: []PubRestriction{
+			{PurposeID: 3, RestrictType: PubRestrictRequireConsent, Vendors: []VendorRange{{StartID: 1, EndID: 5}}},
+		},
+		DisclosedVendors: []uint16{3, 8, 700},
+		AllowedVendors:   []uint16{3},
+		PublisherTC: &PublisherTCBuilder{
+			PurposesConsent:       []consentconstants.Purpose{2},
+			CustomPurposesConsent: []uint8{2},
+		},
+	}
+	canonical, err := builder.EncodeString()
+	assertNilError(t, err)
+
+	bitFields := builder
+	bitFields.VendorEncoding = BitFieldEncoding
+	ranges := builder
+	ranges.VendorEncoding = RangeEncoding
+	ranges.PubRestrictions = []PubRestriction{
+		{PurposeID: 3, RestrictType: PubRestrictRequireConsent, Vendors: []VendorRange{{StartID: 2, EndID: 5}, {StartID: 1, EndID: 1}}},
+	}
+	variants := map[string]ConsentBuilder{"bitfields": bitFields, "ranges": ranges}
+
+	for name, variant := range variants {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := variant.EncodeString()
+			assertNilError(t, err)
+			if encoded == canonical {
+				t.Fatalf("The %s variant should differ from the canonical string %s", name, canonical)
+			}
+			segments := strings.Split(encoded, ".")
+
+			// Append trailing bits to the core string, and reverse the order of the segments
+			core, err := base64.RawURLEncoding.DecodeString(segments[0])
+			assertNilError(t, err)
+			messy := []string{base64.RawURLEncoding.EncodeToString(append(core, 0, 0, 0))}
+			for i := len(segments) - 1; i > 0; i-- {
+				messy = append(messy, segments[i])
+			}
+			messy = append(messy, "4A") // An unknown segment type
+
+			for _, test := range []string{encoded, strings.Join(messy, ".")} {
+				consent, err := ParseString(test)
+				assertNilError(t, err)
+				actual, err := EncodeCanonical(consent)
+				assertNilError(t, err)
+				assertStringsEqual(t, canonical, actual)
+			}
+		})
+	}
+}
+
+func TestEncodeCanonicalKeepsEmptySegments(t *testing.T) {
+	consent, err := ParseString(coreString + ".IAAA.QAAA")
+	assertNilError(t, err)
+	encoded, err := EncodeCanonical(consent)
+	assertNilError(t, err)
+	assertStringsEqual(t, coreString+".IAAA.QAAA", encoded)
+}
+
+func TestMergeRanges(t *testing.T) {
+	merged := mergeRanges([]VendorRange{
+		{StartID: 10, EndID: 12},
+		{StartID: 1, EndID: 3},
+		{StartID: 4, EndID: 4},
+		{StartID: 11, EndID: 11},
+		{StartID: 20, EndID: 65535},
+		{StartID: 65535, EndID: 65535},
+	})
+	expected := []VendorRange{{StartID: 1, EndID: 4}, {StartID: 10, EndID: 12}, {StartID: 20, EndID: 65535}}
+	if !reflect.DeepEqual(expected, merged) {
+		t.Errorf("Merged ranges were not equal. Expected %+v, actual %+v", expected, merged)
+	}
+}
+
+func TestEncodeCanonicalDropsReservedRestrictionType(t *testing.T) {
+	builder := ConsentBuilder{ConsentLanguage: "EN", VendorListVersion: 12, PublisherCC: "FR", VendorConsents: []uint16{3}}
+	canonical, err := builder.EncodeString()
+	assertNilError(t, err)
+
+	// Turn a RequireLI restriction into one of the reserved type 3
+	builder.PubRestrictions = []PubRestriction{
+		{PurposeID: 3, RestrictType: PubRestrictRequireLI, Vendors: []VendorRange{{StartID: 1, EndID: 5}}},
+	}
+	data, err := builder.Encode()
+	assertNilError(t, err)
+	parsed, err := Parse(data)
+	assertNilError(t, err)
+	// The type follows the 12 bits of NumPubRestrictions and the 6 bits of the PurposeId
+	setBit(data, parsed.(ConsentMetadata).pubRestrictionsStart+19)
+
+	consent, err := ParseString(base64.RawURLEncoding.EncodeToString(data))
+	assertNilError(t, err)
+	restrictions := consent.PubRestrictions()
+	if len(restrictions) != 1 || restrictions[0].RestrictType != 3 {
+		t.Fatalf("Expected a single restriction of type 3, got %+v", restrictions)
+	}
+	encoded, err := EncodeCanonical(consent)
+	assertNilError(t, err)
+	assertStringsEqual(t, canonical, encoded)
+}
+
+func TestEncodeCanonicalKeepsRawLetters(t *testing.T) {
+	builder := ConsentBuilder{ConsentLanguage: "EN", VendorListVersion: 12, PublisherCC: "FR", VendorConsents: []uint16{3}}
+	data, err := builder.Encode()
+	assertNilError(t, err)
+	// Give the first letter of the ConsentLanguage (bits 108-113) and both letters of the PublisherCC (bits 201-212)
+	// the 6-bit value 63, which isn't a letter
+	for _, bit := range []uint{108, 109, 110, 111, 112, 113, 201, 202, 203, 204, 205, 206, 207, 208, 209, 210, 211, 212} {
+		setBit(data, bit)
+	}
+	consent, err := Parse(data)
+	assertNilError(t, err)
+
+	encoded, err := EncodeCanonical(consent)
+	assertNilError(t, err)
+	canonical, err := ParseString(encoded)
+	assertNilError(t, err)
+	assertStringsEqual(t, consent.ConsentLanguage(), canonical.ConsentLanguage())
+	assertStringsEqual(t, consent.PublisherCC(), canonical.PublisherCC())
+	assertStringsEqual(t, string([]byte{'A' + 63, 'A' + 63}), canonical.PublisherCC())
+
+	again, err := EncodeCanonical(canonical)
+	assertNilError(t, err)
+	assertStringsEqual(t, encoded, again)
+}
+
+func setBit(data []byte, bitIndex uint) {
+	data[bitIndex/8] |= 0x80 >> (bitIndex % 8)
+}
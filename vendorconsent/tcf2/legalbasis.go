@@ -0,0 +1,118 @@
+package vendorconsent
+
+import (
+	"github.com/prebid/go-gdpr/api"
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+// EffectiveLegalBasis returns the legal basis which the vendor must rely on for the purpose,
+// once the publisher restrictions of the consent string apply. It doesn't check whether that legal basis
+// was actually established; that's up to PurposeAllowed and VendorConsent, or PurposeLITransparency and VendorLegitInterest.
+//
+// The vendor is the vendorID's entry in the Global Vendor List. Following the TCF specification:
+//
+//   - Purposes which the vendor didn't declare resolve to LegalBasisNone.
+//   - A restriction of type 0 (not allowed) resolves to LegalBasisNone.
+//   - On a flexible purpose, a restriction of type 1 (require consent) resolves to LegalBasisConsent, and one
+//     of type 2 (require legitimate interest) resolves to LegalBasisLegitimateInterest. Without a restriction,
+//     the vendor's default legal basis applies.
+//   - On a purpose which isn't flexible, a restriction which requires the other legal basis than the declared one
+//     resolves to LegalBasisNone, since the vendor can't switch.
+//
+// If the publisher sets both restriction types 1 and 2 on the purpose, the vendor gets LegalBasisConsent.
+func EffectiveLegalBasis(consent VendorConsents, vendorID uint16, vendor api.Vendor, purpose consentconstants.Purpose) consentconstants.LegalBasis {
+	if vendor == nil {
+		return consentconstants.LegalBasisNone
+	}
+
+	var declared consentconstants.LegalBasis
+	switch {
+	case vendor.PurposeStrict(purpose):
+		declared = consentconstants.LegalBasisConsent
+	case vendor.LegitimateInterestStrict(purpose):
+		declared = consentconstants.LegalBasisLegitimateInterest
+	default:
+		return consentconstants.LegalBasisNone
+	}
+	// Flexible purposes also show up in Purpose and LegitimateInterest, on top of the declared legal basis
+	flexible := vendor.Purpose(purpose) && vendor.LegitimateInterest(purpose)
+
+	if consent == nil {
+		return declared
+	}
+	restrictions := consent.VendorPubRestrictions(vendorID)
+	restrictType, restricted := restrictions[uint8(purpose)]
+	if !restricted {
+		return declared
+	}
+
+	var required consentconstants.LegalBasis
+	switch restrictType {
+	case PubRestrictNotAllowed:
+		return consentconstants.LegalBasisNone
+	case PubRestrictRequireConsent:
+		required = consentconstants.LegalBasisConsent
+	case PubRestrictRequireLI:
+		required = consentconstants.LegalBasisLegitimateInterest
+	default:
+		// Undefined restriction types don't change anything
+		return declared
+	}
+	if required != declared && !flexible {
+		return consentconstants.LegalBasisNone
+	}
+	return required
+}
+
+// EffectiveLegalBases maps every purpose (1 to 24) to the result of EffectiveLegalBasis.
+// Purposes which resolve to LegalBasisNone are left out.
+func EffectiveLegalBases(consent VendorConsents, vendorID uint16, vendor api.Vendor) map[consentconstants.Purpose]consentconstants.LegalBasis {
+	bases := make(map[consentconstants.Purpose]consentconstants.LegalBasis)
+	for purpose := consentconstants.Purpose(1); purpose <= 24; purpose++ {
+		if basis := EffectiveLegalBasis(consent, vendorID, vendor, purpose); basis != consentconstants.LegalBasisNone {
+			bases[purpose] = basis
+		}
+	}
+	return bases
+}
+
+// VendorPurposeLegalBasis returns the legal basis which the vendor established for the purpose, or LegalBasisNone
+// if the vendor may not process personal data for it. The vendor is the vendorID's entry in the Global Vendor List.
+//
+// The legal basis from EffectiveLegalBasis must be established by the consent string: PurposeAllowed and VendorConsent
+// for LegalBasisConsent, or PurposeLITransparency and VendorLegitInterest for LegalBasisLegitimateInterest.
+// Legitimate interest is never allowed for purpose 1, nor for purposes 3 to 6 once the TCFPolicyVersion is 4 (TCF 2.2)
+// or later. On those purposes, flexible vendors fall back to consent, unless the publisher requires legitimate interest.
+// This follows the TCFPolicyVersion alone; the enforcement package also applies the TCF 2.2 rules to vendor lists
+// of specification version 3.
+func (c ConsentMetadata) VendorPurposeLegalBasis(vendorID uint16, vendor api.Vendor, purpose consentconstants.Purpose) consentconstants.LegalBasis {
+	effective := EffectiveLegalBasis(c, vendorID, vendor, purpose)
+	if effective == consentconstants.LegalBasisLegitimateInterest && legitimateInterestForbidden(c.TCFPolicyVersion(), purpose) {
+		flexible := vendor.Purpose(purpose) && vendor.LegitimateInterest(purpose)
+		if !flexible || c.VendorPubRestrictions(vendorID)[uint8(purpose)] == PubRestrictRequireLI {
+			return consentconstants.LegalBasisNone
+		}
+		effective = consentconstants.LegalBasisConsent
+	}
+
+	switch effective {
+	case consentconstants.LegalBasisConsent:
+		if c.PurposeAllowed(purpose) && c.VendorConsent(vendorID) {
+			return consentconstants.LegalBasisConsent
+		}
+	case consentconstants.LegalBasisLegitimateInterest:
+		if c.PurposeLITransparency(purpose) && c.VendorLegitInterest(vendorID) {
+			return consentconstants.LegalBasisLegitimateInterest
+		}
+	}
+	return consentconstants.LegalBasisNone
+}
+
+// legitimateInterestForbidden returns true if no vendor may rely on legitimate interest for the purpose
+// under the TCF policy version
+func legitimateInterestForbidden(tcfPolicyVersion uint8, purpose consentconstants.Purpose) bool {
+	if purpose == consentconstants.InfoStorageAccess {
+		return true
+	}
+	return tcfPolicyVersion >= 4 && purpose >= 3 && purpose <= 6
+}
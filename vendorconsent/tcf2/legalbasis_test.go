@@ -0,0 +1,306 @@
+package vendorconsent
+
+import (
+	"testing"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+func TestEffectiveLegalBasis(t *testing.T) {
+	// Purposes 2 and 3 are flexible, with consent and legitimate interest as their default legal basis
+	vendor := fakeVendor{
+		purposes:            []consentconstants.Purpose{1, 2, 4},
+		legitimateInterests: []consentconstants.Purpose{3, 5},
+		flexiblePurposes:    []consentconstants.Purpose{2, 3},
+	}
+	tests := []struct {
+		name         string
+		purpose      consentconstants.Purpose
+		restrictions []uint8
+		expected     consentconstants.LegalBasis
+	}{
+		{name: "not_declared", purpose: 6, expected: consentconstants.LegalBasisNone},
+		{name: "not_declared_restricted", purpose: 6, restrictions: []uint8{PubRestrictRequireConsent}, expected: consentconstants.LegalBasisNone},
+		{name: "consent", purpose: 4, expected: consentconstants.LegalBasisConsent},
+		{name: "legitimate_interest", purpose: 5, expected: consentconstants.LegalBasisLegitimateInterest},
+		{name: "flexible_default_consent", purpose: 2, expected: consentconstants.LegalBasisConsent},
+		{name: "flexible_default_legitimate_interest", purpose: 3, expected: consentconstants.LegalBasisLegitimateInterest},
+		{name: "flexible_require_consent", purpose: 3, restrictions: []uint8{PubRestrictRequireConsent}, expected: consentconstants.LegalBasisConsent},
+		{name: "flexible_require_legitimate_interest", purpose: 2, restrictions: []uint8{PubRestrictRequireLI}, expected: consentconstants.LegalBasisLegitimateInterest},
+		{name: "flexible_require_same", purpose: 2, restrictions: []uint8{PubRestrictRequireConsent}, expected: consentconstants.LegalBasisConsent},
+		{name: "flexible_not_allowed", purpose: 2, restrictions: []uint8{PubRestrictNotAllowed}, expected: consentconstants.LegalBasisNone},
+		{name: "flexible_both_requirements", purpose: 3, restrictions: []uint8{PubRestrictRequireLI, PubRestrictRequireConsent}, expected: consentconstants.LegalBasisConsent},
+		{name: "fixed_require_same", purpose: 4, restrictions: []uint8{PubRestrictRequireConsent}, expected: consentconstants.LegalBasisConsent},
+		{name: "fixed_require_consent", purpose: 5, restrictions: []uint8{PubRestrictRequireConsent}, expected: consentconstants.LegalBasisNone},
+		{name: "fixed_require_legitimate_interest", purpose: 4, restrictions: []uint8{PubRestrictRequireLI}, expected: consentconstants.LegalBasisNone},
+		{name: "fixed_not_allowed", purpose: 5, restrictions: []uint8{PubRestrictNotAllowed}, expected: consentconstants.LegalBasisNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := ConsentBuilder{ConsentLanguage: "EN", VendorListVersion: 1, PublisherCC: "FR"}
+			for _, restrictType := range tt.restrictions {
+				builder.PubRestrictions = append(builder.PubRestrictions, PubRestriction{
+					PurposeID:    uint8(tt.purpose),
+					RestrictType: restrictType,
+					Vendors:      []VendorRange{{StartID: 5, EndID: 9}},
+				})
+			}
+			encoded, err := builder.EncodeString()
+			assertNilError(t, err)
+			consent, err := ParseString(encoded)
+			assertNilError(t, err)
+
+			assertLegalBasesEqual(t, tt.expected, EffectiveLegalBasis(consent, 7, vendor, tt.purpose))
+			// The restrictions only apply to vendors 5 to 9
+			assertLegalBasesEqual(t, EffectiveLegalBasis(nil, 7, vendor, tt.purpose), EffectiveLegalBasis(consent, 10, vendor, tt.purpose))
+		})
+	}
+}
+
+func TestEffectiveLegalBases(t *testing.T) {
+	vendor := fakeVendor{
+		purposes:            []consentconstants.Purpose{1, 2},
+		legitimateInterests: []consentconstants.Purpose{7},
+		flexiblePurposes:    []consentconstants.Purpose{2},
+	}
+	builder := ConsentBuilder{
+		ConsentLanguage:   "EN",
+		VendorListVersion: 1,
+		PublisherCC:       "FR",
+		PubRestrictions: []PubRestriction{
+			{PurposeID: 1, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 1, EndID: 1}}},
+			{PurposeID: 2, RestrictType: PubRestrictRequireLI, Vendors: []VendorRange{{StartID: 1, EndID: 1}}},
+		},
+	}
+	encoded, err := builder.EncodeString()
+	assertNilError(t, err)
+	consent, err := ParseString(encoded)
+	assertNilError(t, err)
+
+	bases := EffectiveLegalBases(consent, 1, vendor)
+	assertIntsEqual(t, 2, len(bases))
+	assertLegalBasesEqual(t, consentconstants.LegalBasisLegitimateInterest, bases[2])
+	assertLegalBasesEqual(t, consentconstants.LegalBasisLegitimateInterest, bases[7])
+
+	assertIntsEqual(t, 0, len(EffectiveLegalBases(consent, 1, nil)))
+}
+
+func TestVendorPurposeLegalBasis(t *testing.T) {
+	// Purposes 2 and 3 are flexible, with consent and legitimate interest as their default legal basis
+	flexibleVendor := fakeVendor{
+		purposes:            []consentconstants.Purpose{1, 2},
+		legitimateInterests: []consentconstants.Purpose{3, 4},
+		flexiblePurposes:    []consentconstants.Purpose{2, 3},
+	}
+	// Every consent and legitimate interest bit is set, so only the restrictions decide
+	base := ConsentBuilder{
+		ConsentLanguage:           "EN",
+		VendorListVersion:         1,
+		PublisherCC:               "FR",
+		PurposesConsent:           []consentconstants.Purpose{1, 2, 3, 4},
+		PurposesLITransparency:    []consentconstants.Purpose{1, 2, 3, 4},
+		VendorConsents:            []uint16{5, 6, 7},
+		VendorLegitimateInterests: []uint16{5, 6, 7},
+	}
+	tests := []struct {
+		name         string
+		purpose      consentconstants.Purpose
+		restrictions []PubRestriction
+		expected     consentconstants.LegalBasis
+	}{
+		{name: "flexible_default_consent", purpose: 2, expected: consentconstants.LegalBasisConsent},
+		{name: "flexible_default_legitimate_interest", purpose: 3, expected: consentconstants.LegalBasisLegitimateInterest},
+		{
+			name:    "flexible_not_allowed",
+			purpose: 2,
+			restrictions: []PubRestriction{
+				{PurposeID: 2, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 7, EndID: 7}}},
+			},
+			expected: consentconstants.LegalBasisNone,
+		},
+		{
+			name:    "flexible_not_allowed_and_require_legitimate_interest",
+			purpose: 2,
+			restrictions: []PubRestriction{
+				{PurposeID: 2, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 7, EndID: 9}}},
+				{PurposeID: 2, RestrictType: PubRestrictRequireLI, Vendors: []VendorRange{{StartID: 5, EndID: 7}}},
+			},
+			expected: consentconstants.LegalBasisNone,
+		},
+		{
+			name:    "flexible_not_allowed_and_require_consent",
+			purpose: 3,
+			restrictions: []PubRestriction{
+				{PurposeID: 3, RestrictType: PubRestrictRequireConsent, Vendors: []VendorRange{{StartID: 7, EndID: 7}}},
+				{PurposeID: 3, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 1, EndID: 10}}},
+			},
+			expected: consentconstants.LegalBasisNone,
+		},
+		{
+			name:    "flexible_not_allowed_on_other_purpose",
+			purpose: 3,
+			restrictions: []PubRestriction{
+				{PurposeID: 2, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 7, EndID: 7}}},
+				{PurposeID: 3, RestrictType: PubRestrictRequireConsent, Vendors: []VendorRange{{StartID: 7, EndID: 7}}},
+			},
+			expected: consentconstants.LegalBasisConsent,
+		},
+		{
+			name:    "flexible_not_allowed_on_other_vendor",
+			purpose: 2,
+			restrictions: []PubRestriction{
+				{PurposeID: 2, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 5, EndID: 6}}},
+				{PurposeID: 2, RestrictType: PubRestrictRequireLI, Vendors: []VendorRange{{StartID: 7, EndID: 7}}},
+			},
+			expected: consentconstants.LegalBasisLegitimateInterest,
+		},
+		{
+			name:    "fixed_not_allowed",
+			purpose: 4,
+			restrictions: []PubRestriction{
+				{PurposeID: 4, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 7, EndID: 7}}},
+			},
+			expected: consentconstants.LegalBasisNone,
+		},
+		{name: "fixed_legitimate_interest", purpose: 4, expected: consentconstants.LegalBasisLegitimateInterest},
+		{name: "not_declared", purpose: 5, expected: consentconstants.LegalBasisNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := base
+			builder.PubRestrictions = tt.restrictions
+			encoded, err := builder.EncodeString()
+			assertNilError(t, err)
+			consent, err := ParseString(encoded)
+			assertNilError(t, err)
+
+			assertLegalBasesEqual(t, tt.expected, consent.VendorPurposeLegalBasis(7, flexibleVendor, tt.purpose))
+		})
+	}
+}
+
+func TestVendorPurposeLegalBasisBits(t *testing.T) {
+	vendor := fakeVendor{
+		purposes:            []consentconstants.Purpose{2},
+		legitimateInterests: []consentconstants.Purpose{1, 3},
+	}
+	builder := ConsentBuilder{
+		ConsentLanguage:           "EN",
+		VendorListVersion:         1,
+		PublisherCC:               "FR",
+		PurposesConsent:           []consentconstants.Purpose{2, 3},
+		PurposesLITransparency:    []consentconstants.Purpose{1, 2},
+		VendorConsents:            []uint16{1, 2},
+		VendorLegitimateInterests: []uint16{1},
+	}
+	encoded, err := builder.EncodeString()
+	assertNilError(t, err)
+	consent, err := ParseString(encoded)
+	assertNilError(t, err)
+
+	assertLegalBasesEqual(t, consentconstants.LegalBasisConsent, consent.VendorPurposeLegalBasis(2, vendor, 2))
+	// Vendor 3 has no consent
+	assertLegalBasesEqual(t, consentconstants.LegalBasisNone, consent.VendorPurposeLegalBasis(3, vendor, 2))
+	// Purpose 3 has consent, but the vendor relies on legitimate interest, which wasn't disclosed
+	assertLegalBasesEqual(t, consentconstants.LegalBasisNone, consent.VendorPurposeLegalBasis(1, vendor, 3))
+	// Purpose 1 always requires consent
+	assertLegalBasesEqual(t, consentconstants.LegalBasisNone, consent.VendorPurposeLegalBasis(1, vendor, 1))
+	assertLegalBasesEqual(t, consentconstants.LegalBasisNone, consent.VendorPurposeLegalBasis(1, nil, 2))
+}
+
+func TestVendorPurposeLegalBasisPolicyVersion(t *testing.T) {
+	// Purpose 3 is flexible, with legitimate interest as its default legal basis
+	flexibleVendor := fakeVendor{legitimateInterests: []consentconstants.Purpose{2, 3}, flexiblePurposes: []consentconstants.Purpose{3}}
+	fixedVendor := fakeVendor{legitimateInterests: []consentconstants.Purpose{2, 3}}
+	builder := ConsentBuilder{
+		ConsentLanguage:           "EN",
+		VendorListVersion:         1,
+		PublisherCC:               "FR",
+		PurposesConsent:           []consentconstants.Purpose{2, 3},
+		PurposesLITransparency:    []consentconstants.Purpose{2, 3},
+		VendorConsents:            []uint16{5, 6},
+		VendorLegitimateInterests: []uint16{5, 6},
+		PubRestrictions: []PubRestriction{
+			{PurposeID: 3, RestrictType: PubRestrictRequireLI, Vendors: []VendorRange{{StartID: 6, EndID: 6}}},
+		},
+	}
+	tests := []struct {
+		name          string
+		policyVersion uint8
+		vendorID      uint16
+		vendor        fakeVendor
+		purpose       consentconstants.Purpose
+		expected      consentconstants.LegalBasis
+	}{
+		{name: "tcf2.0_purpose_3", policyVersion: 2, vendorID: 5, vendor: fixedVendor, purpose: 3, expected: consentconstants.LegalBasisLegitimateInterest},
+		{name: "tcf2.2_purpose_3", policyVersion: 4, vendorID: 5, vendor: fixedVendor, purpose: 3, expected: consentconstants.LegalBasisNone},
+		{name: "tcf2.2_purpose_2", policyVersion: 4, vendorID: 5, vendor: fixedVendor, purpose: 2, expected: consentconstants.LegalBasisLegitimateInterest},
+		{name: "tcf2.2_flexible_falls_back_to_consent", policyVersion: 4, vendorID: 5, vendor: flexibleVendor, purpose: 3, expected: consentconstants.LegalBasisConsent},
+		{name: "tcf2.2_flexible_required_legitimate_interest", policyVersion: 4, vendorID: 6, vendor: flexibleVendor, purpose: 3, expected: consentconstants.LegalBasisNone},
+		{name: "tcf2.0_flexible_required_legitimate_interest", policyVersion: 2, vendorID: 6, vendor: flexibleVendor, purpose: 3, expected: consentconstants.LegalBasisLegitimateInterest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := builder
+			builder.TCFPolicyVersion = tt.policyVersion
+			encoded, err := builder.EncodeString()
+			assertNilError(t, err)
+			consent, err := ParseString(encoded)
+			assertNilError(t, err)
+			assertLegalBasesEqual(t, tt.expected, consent.VendorPurposeLegalBasis(tt.vendorID, tt.vendor, tt.purpose))
+		})
+	}
+}
+
+// fakeVendor implements api.Vendor the same way as the vendorlist2 package does
+type fakeVendor struct {
+	purposes            []consentconstants.Purpose
+	legitimateInterests []consentconstants.Purpose
+	flexiblePurposes    []consentconstants.Purpose
+	specialFeatures     []consentconstants.SpecialFeature
+}
+
+func (v fakeVendor) Purpose(purposeID consentconstants.Purpose) bool {
+	return containsPurpose(v.purposes, purposeID) || containsPurpose(v.flexiblePurposes, purposeID)
+}
+
+func (v fakeVendor) PurposeStrict(purposeID consentconstants.Purpose) bool {
+	return containsPurpose(v.purposes, purposeID)
+}
+
+func (v fakeVendor) LegitimateInterest(purposeID consentconstants.Purpose) bool {
+	return containsPurpose(v.legitimateInterests, purposeID) || containsPurpose(v.flexiblePurposes, purposeID)
+}
+
+func (v fakeVendor) LegitimateInterestStrict(purposeID consentconstants.Purpose) bool {
+	return containsPurpose(v.legitimateInterests, purposeID)
+}
+
+func (v fakeVendor) SpecialPurpose(purposeID consentconstants.Purpose) bool {
+	return false
+}
+
+func (v fakeVendor) SpecialFeature(featureID consentconstants.SpecialFeature) bool {
+	for _, feature := range v.specialFeatures {
+		if feature == featureID {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPurpose(purposes []consentconstants.Purpose, purposeID consentconstants.Purpose) bool {
+	for _, purpose := range purposes {
+		if purpose == purposeID {
+			return true
+		}
+	}
+	return false
+}
+
+func assertLegalBasesEqual(t *testing.T, expected consentconstants.LegalBasis, actual consentconstants.LegalBasis) {
+	t.Helper()
+	if expected != actual {
+		t.Errorf("Legal bases were not equal. Expected %s, actual %s", expected, actual)
+	}
+}
@@ -16,6 +16,7 @@ var (
 // parseMetadata parses the metadata from the consent string.
 // This returns an error if the input is too short to answer questions about that data.
 func parseMetadata(data []byte) (ConsentMetadata, error) {
+	// Every field up to and including the MaxVendorID (bits 0 to 229) must be addressable.
 	if len(data) < 29 {
 		return ConsentMetadata{}, fmt.Errorf("vendor consent strings are at least 29 bytes long. This one was %d", len(data))
 	}
@@ -45,15 +46,24 @@ type ConsentMetadata struct {
 	vendorConsents                vendorConsentsResolver
 	vendorLegitimateInterests     vendorConsentsResolver
 	publisherRestrictions         pubRestrictResolver
+	disclosedVendors              vendorConsentsResolver
+	allowedVendors                vendorConsentsResolver
+	publisherTC                   *publisherTC
+	// encodedSegments holds the base64 strings of every segment read by ParseString, the core string included,
+	// in their original order. EncodeLossless writes them back as they were, unknown segment types and pad bits included.
+	encodedSegments []string
 }
 
 type vendorConsentsResolver interface {
 	MaxVendorID() uint16
 	VendorConsent(id uint16) bool
+	VendorIDs() []uint16
 }
 
 type pubRestrictResolver interface {
 	CheckPubRestriction(purposeID uint8, restrictType uint8, vendor uint16) bool
+	PubRestrictions() []PubRestriction
+	VendorPubRestrictions(vendor uint16) map[uint8]uint8
 }
 
 // Version returns the version stored in the first 6 bits
@@ -149,6 +159,18 @@ func (c ConsentMetadata) TCFPolicyVersion() uint8 {
 	return uint8(((c.data[16] & 0x0f) << 2) | (c.data[17] & 0xc0) >> 6)
 }
 
+// IsServiceSpecific returns true if the consent string applies only to the service that created it,
+// and false if it is a globally-scoped consent string. Stored in bit 139
+func (c ConsentMetadata) IsServiceSpecific() bool {
+	return isSet(c.data, 138)
+}
+
+// UseNonStandardTexts returns true if the CMP used customized stack descriptions or purpose/feature
+// texts when asking for consent, info stored in bit 140
+func (c ConsentMetadata) UseNonStandardTexts() bool {
+	return isSet(c.data, 139)
+}
+
 // MaxVendorID returns the maximum value for vendor identifier in bits 214 to 229
 func (c ConsentMetadata) MaxVendorID() uint16 {
 	// The max vendor ID is stored in bits 214 - 229
@@ -182,6 +204,15 @@ func (c ConsentMetadata) PurposeOneTreatment() bool {
 	return isSet(c.data, 200)
 }
 
+// PublisherCC returns the two letter ISO 3166-1 alpha-2 country code of the publisher, stored in bits 202 to 213
+func (c ConsentMetadata) PublisherCC() string {
+	// Stored in bits 202-213... which is [0xxxxxxx xxxxx000] starting at the 26th byte.
+	// Each letter is stored as 6 bits, with A=0 and Z=25
+	leftChar := (c.data[25] >> 1) & 0x3f
+	rightChar := ((c.data[25] & 0x01) << 5) | c.data[26]>>3
+	return string([]byte{leftChar + 65, rightChar + 65}) // Unicode A-Z is 65-90
+}
+
 // SpecialFeatureOptIn returns if the given special feature is enable, stored in bits 140 to 152
 func (c ConsentMetadata) SpecialFeatureOptIn(id uint16) bool {
 	if id > 12 {
@@ -200,11 +231,85 @@ func (c ConsentMetadata) VendorLegitInterest(id uint16) bool {
 	return c.vendorLegitimateInterests.VendorConsent(id)
 }
 
+// VendorConsentIDs returns the ids of every vendor with consent, in ascending order
+func (c ConsentMetadata) VendorConsentIDs() []uint16 {
+	return c.vendorConsents.VendorIDs()
+}
+
+// VendorLegitInterestIDs returns the ids of every vendor with legitimate interest established, in ascending order
+func (c ConsentMetadata) VendorLegitInterestIDs() []uint16 {
+	return c.vendorLegitimateInterests.VendorIDs()
+}
+
+// HasDisclosedVendorsSegment returns true if the consent string included the Disclosed Vendors segment
+func (c ConsentMetadata) HasDisclosedVendorsSegment() bool {
+	return c.disclosedVendors != nil
+}
+
+// DisclosedVendorMaxID returns the max vendor id of the Disclosed Vendors segment, or 0 if the segment is missing
+func (c ConsentMetadata) DisclosedVendorMaxID() uint16 {
+	if c.disclosedVendors == nil {
+		return 0
+	}
+	return c.disclosedVendors.MaxVendorID()
+}
+
+// VendorDisclosed returns true if the given vendor id was disclosed to the user in the Disclosed Vendors segment.
+// This always returns false if the consent string didn't include that segment.
+func (c ConsentMetadata) VendorDisclosed(id uint16) bool {
+	if c.disclosedVendors == nil {
+		return false
+	}
+	return c.disclosedVendors.VendorConsent(id)
+}
+
+// HasAllowedVendorsSegment returns true if the consent string included the Allowed Vendors segment
+func (c ConsentMetadata) HasAllowedVendorsSegment() bool {
+	return c.allowedVendors != nil
+}
+
+// AllowedVendorMaxID returns the max vendor id of the Allowed Vendors segment, or 0 if the segment is missing
+func (c ConsentMetadata) AllowedVendorMaxID() uint16 {
+	if c.allowedVendors == nil {
+		return 0
+	}
+	return c.allowedVendors.MaxVendorID()
+}
+
+// VendorAllowedOOB returns true if the publisher allows the given vendor id to use out-of-band legal bases,
+// as signaled by the Allowed Vendors segment. This always returns false if the consent string didn't include
+// that segment, so callers should check HasAllowedVendorsSegment to tell both cases apart.
+func (c ConsentMetadata) VendorAllowedOOB(id uint16) bool {
+	if c.allowedVendors == nil {
+		return false
+	}
+	return c.allowedVendors.VendorConsent(id)
+}
+
+// PublisherTC returns the Publisher TC segment, or nil if the consent string didn't include it
+func (c ConsentMetadata) PublisherTC() PublisherTC {
+	if c.publisherTC == nil {
+		return nil
+	}
+	return c.publisherTC
+}
+
 // CheckPubRestriction returns the publisher restriction for a given purpose id, restriction type and vendor id
 func (c ConsentMetadata) CheckPubRestriction(purposeID uint8, restrictType uint8, vendor uint16) bool {
 	return c.publisherRestrictions.CheckPubRestriction(purposeID, restrictType, vendor)
 }
 
+// PubRestrictions returns every publisher restriction in the consent string, sorted by purpose id and restriction type
+func (c ConsentMetadata) PubRestrictions() []PubRestriction {
+	return c.publisherRestrictions.PubRestrictions()
+}
+
+// VendorPubRestrictions returns the restriction type of every purpose the publisher restricted for the given vendor id,
+// keyed by purpose id
+func (c ConsentMetadata) VendorPubRestrictions(vendor uint16) map[uint8]uint8 {
+	return c.publisherRestrictions.VendorPubRestrictions(vendor)
+}
+
 // Returns true if the bitIndex'th bit in data is a 1, and false if it's a 0.
 func isSet(data []byte, bitIndex uint) bool {
 	byteIndex := bitIndex / 8
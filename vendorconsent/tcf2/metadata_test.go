@@ -143,3 +143,62 @@ func TestLITransparency(t *testing.T) {
 	assertBoolsEqual(t, false, consent.PurposeLITransparency(28))
 
 }
+
+func TestServiceSpecificAndNonStandardTexts(t *testing.T) {
+	baseConsent := "CPtGDMAPtGDMALMAAAENA_C_AAAAAAAAACiQAAAAAAAA"
+	index := 23 // IsServiceSpecific and UseNonStandardTexts are the first two bits of the 24th 6-bit base64 position
+	tests := []struct {
+		name                string
+		base64Char          string
+		isServiceSpecific   bool
+		useNonStandardTexts bool
+	}{
+		{
+			name:                "char_A_bits_000000_is_global_with_standard_texts",
+			base64Char:          "A",
+			isServiceSpecific:   false,
+			useNonStandardTexts: false,
+		},
+		{
+			name:                "char_g_bits_100000_is_service_specific",
+			base64Char:          "g",
+			isServiceSpecific:   true,
+			useNonStandardTexts: false,
+		},
+		{
+			name:                "char_Q_bits_010000_uses_non_standard_texts",
+			base64Char:          "Q",
+			isServiceSpecific:   false,
+			useNonStandardTexts: true,
+		},
+		{
+			name:                "char_w_bits_110000_is_service_specific_with_non_standard_texts",
+			base64Char:          "w",
+			isServiceSpecific:   true,
+			useNonStandardTexts: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updatedConsent := baseConsent[:index] + tt.base64Char + baseConsent[index+1:]
+			consent, err := Parse(decode(t, updatedConsent))
+			assertNilError(t, err)
+			assertBoolsEqual(t, tt.isServiceSpecific, consent.IsServiceSpecific())
+			assertBoolsEqual(t, tt.useNonStandardTexts, consent.UseNonStandardTexts())
+		})
+	}
+}
+
+func TestPublisherCC(t *testing.T) {
+	consent, err := ParseString("CPtGDMAPtGDMALMAAAENA_C_AAAAAAAAACiQAAAAAAAA")
+	assertNilError(t, err)
+	assertStringsEqual(t, "US", consent.PublisherCC())
+
+	consent, err = ParseString("COwGVJOOwGVJOADACHENAOCAAO6as_-AAAhoAFNLAAoAAAA")
+	assertNilError(t, err)
+	assertStringsEqual(t, "EN", consent.PublisherCC())
+
+	consent, err = ParseString("COx3XOeOx3XOeLkAAAENAfCIAAAAAHgAAIAAAAAAAAAA")
+	assertNilError(t, err)
+	assertStringsEqual(t, "AA", consent.PublisherCC())
+}
@@ -0,0 +1,95 @@
+package vendorconsent
+
+import (
+	"fmt"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+// PublisherTC is the Publisher Purposes Transparency and Consent segment of a TCF 2.0 consent string.
+// It holds the legal bases which the user established for the publisher itself, rather than for vendors.
+type PublisherTC interface {
+	// PurposeConsent returns true if the user consented to the publisher using data for the given purpose (1 to 24 max).
+	PurposeConsent(id consentconstants.Purpose) bool
+
+	// PurposeLITransparency returns true if the publisher's legitimate interest for the given purpose (1 to 24 max)
+	// was disclosed to the user, and the user didn't object to it.
+	PurposeLITransparency(id consentconstants.Purpose) bool
+
+	// NumCustomPurposes returns the number of custom purposes the publisher defined.
+	// This is the upper bound (inclusive) on valid inputs for the custom purpose functions.
+	NumCustomPurposes() uint8
+
+	// CustomPurposeConsent returns true if the user consented to the given custom purpose.
+	CustomPurposeConsent(id uint8) bool
+
+	// CustomPurposeLITransparency returns true if the publisher's legitimate interest for the given custom purpose
+	// was disclosed to the user, and the user didn't object to it.
+	CustomPurposeLITransparency(id uint8) bool
+}
+
+// parsePublisherTC parses the Publisher TC segment. The layout of the segment is:
+//
+//	SegmentType (bits 0-2), PubPurposesConsent (bits 3-26), PubPurposesLITransparency (bits 27-50),
+//	NumCustomPurposes (bits 51-56), CustomPurposesConsent (NumCustomPurposes bits) and
+//	CustomPurposesLITransparency (NumCustomPurposes bits)
+func parsePublisherTC(data []byte) (*publisherTC, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("Error on parsing the number of custom purposes: a Publisher TC segment requires at least 8 bytes. This segment had %d", len(data))
+	}
+	// Stored in bits 51-56... which is [00011111 10000000] starting at the 7th byte
+	numCustomPurposes := (data[6]&0x1f)<<1 | data[7]>>7
+
+	// add 7 to force rounding to next integer value
+	bytesRequired := (57 + 2*uint(numCustomPurposes) + 7) / 8
+	if uint(len(data)) < bytesRequired {
+		return nil, fmt.Errorf("Error on parsing the custom purposes: a Publisher TC segment with %d custom purposes requires %d bytes. This segment had %d", numCustomPurposes, bytesRequired, len(data))
+	}
+
+	return &publisherTC{
+		data:              data,
+		numCustomPurposes: numCustomPurposes,
+	}, nil
+}
+
+// publisherTC implements PublisherTC. This relies on parsePublisherTC to have validated the length of the data.
+type publisherTC struct {
+	data              []byte
+	numCustomPurposes uint8
+}
+
+func (p *publisherTC) PurposeConsent(id consentconstants.Purpose) bool {
+	// Stored in bits 3-26
+	if id < 1 || id > 24 {
+		return false
+	}
+	return isSet(p.data, uint(id)+2)
+}
+
+func (p *publisherTC) PurposeLITransparency(id consentconstants.Purpose) bool {
+	// Stored in bits 27-50
+	if id < 1 || id > 24 {
+		return false
+	}
+	return isSet(p.data, uint(id)+26)
+}
+
+func (p *publisherTC) NumCustomPurposes() uint8 {
+	return p.numCustomPurposes
+}
+
+func (p *publisherTC) CustomPurposeConsent(id uint8) bool {
+	// Stored in the NumCustomPurposes bits starting at bit 57
+	if id < 1 || id > p.numCustomPurposes {
+		return false
+	}
+	return isSet(p.data, 57+uint(id)-1)
+}
+
+func (p *publisherTC) CustomPurposeLITransparency(id uint8) bool {
+	// Stored in the NumCustomPurposes bits which follow the CustomPurposesConsent
+	if id < 1 || id > p.numCustomPurposes {
+		return false
+	}
+	return isSet(p.data, 57+uint(p.numCustomPurposes)+uint(id)-1)
+}
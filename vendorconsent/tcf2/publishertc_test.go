@@ -0,0 +1,94 @@
+package vendorconsent
+
+import (
+	"testing"
+
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+func TestPublisherTC(t *testing.T) {
+	// Publisher TC segment with purpose consents 1, 2 and 7, purpose LI transparency 2, 10 and 24,
+	// and 3 custom purposes: consent for custom purposes 1 and 3, LI transparency for custom purpose 2
+	consent, err := ParseString(coreString + ".eEAACAgAIdQ")
+	assertNilError(t, err)
+	publisherTC := consent.PublisherTC()
+	if publisherTC == nil {
+		t.Fatal("The Publisher TC segment should have been parsed")
+	}
+
+	purposesConsent := buildMap(1, 2, 7)
+	purposesLITransparency := buildMap(2, 10, 24)
+	for i := uint8(0); i <= 25; i++ {
+		_, ok := purposesConsent[uint(i)]
+		assertBoolsEqual(t, ok, publisherTC.PurposeConsent(consentconstants.Purpose(i)))
+		_, ok = purposesLITransparency[uint(i)]
+		assertBoolsEqual(t, ok, publisherTC.PurposeLITransparency(consentconstants.Purpose(i)))
+	}
+
+	assertUInt8sEqual(t, 3, publisherTC.NumCustomPurposes())
+	customPurposesConsent := buildMap(1, 3)
+	customPurposesLITransparency := buildMap(2)
+	for i := uint8(0); i <= 4; i++ {
+		_, ok := customPurposesConsent[uint(i)]
+		assertBoolsEqual(t, ok, publisherTC.CustomPurposeConsent(i))
+		_, ok = customPurposesLITransparency[uint(i)]
+		assertBoolsEqual(t, ok, publisherTC.CustomPurposeLITransparency(i))
+	}
+}
+
+func TestPublisherTCWithoutCustomPurposes(t *testing.T) {
+	consent, err := ParseString(coreString + ".IAFKBA.cAAAAAAAAAA")
+	assertNilError(t, err)
+	publisherTC := consent.PublisherTC()
+	if publisherTC == nil {
+		t.Fatal("The Publisher TC segment should have been parsed")
+	}
+	assertBoolsEqual(t, true, publisherTC.PurposeConsent(1))
+	assertBoolsEqual(t, false, publisherTC.PurposeLITransparency(1))
+	assertUInt8sEqual(t, 0, publisherTC.NumCustomPurposes())
+	assertBoolsEqual(t, false, publisherTC.CustomPurposeConsent(1))
+}
+
+func TestNoPublisherTC(t *testing.T) {
+	consent, err := ParseString(coreString + ".IAFKBA")
+	assertNilError(t, err)
+	if consent.PublisherTC() != nil {
+		t.Error("The Publisher TC segment should be nil, but wasn't.")
+	}
+}
+
+func TestInvalidPublisherTC(t *testing.T) {
+	tests := []struct {
+		name        string
+		consent     string
+		expectError string
+	}{
+		{
+			name:        "missing_num_custom_purposes",
+			consent:     coreString + ".cAAAAAAAAA",
+			expectError: "Error on parsing the Publisher TC segment (segment 1): Error on parsing the number of custom purposes: a Publisher TC segment requires at least 8 bytes. This segment had 7",
+		},
+		{
+			name:        "missing_custom_purposes",
+			consent:     coreString + ".cAAAAAAABUAA",
+			expectError: "Error on parsing the Publisher TC segment (segment 1): Error on parsing the custom purposes: a Publisher TC segment with 10 custom purposes requires 10 bytes. This segment had 9",
+		},
+		{
+			name:        "publisher_tc_after_another_segment",
+			consent:     coreString + ".IAFKBA.cAAAAAAAAA",
+			expectError: "Error on parsing the Publisher TC segment (segment 2): Error on parsing the number of custom purposes: a Publisher TC segment requires at least 8 bytes. This segment had 7",
+		},
+		{
+			name:        "duplicate_publisher_tc",
+			consent:     coreString + ".cAAAAAAAAAA.cAAAAAAAAAA",
+			expectError: "segment 2 is a duplicate Publisher TC segment",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseString(tt.consent)
+			assertError(t, err)
+			assertStringsEqual(t, tt.expectError, err.Error())
+		})
+	}
+}
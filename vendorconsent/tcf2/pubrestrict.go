@@ -2,10 +2,34 @@ package vendorconsent
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/prebid/go-gdpr/bitutils"
 )
 
+// Publisher restriction types, as defined by the TCF 2.0 spec
+const (
+	// PubRestrictNotAllowed means that the vendor is not allowed to process data for the purpose
+	PubRestrictNotAllowed uint8 = 0
+	// PubRestrictRequireConsent means that the vendor must rely on consent for the purpose, if it is flexible
+	PubRestrictRequireConsent uint8 = 1
+	// PubRestrictRequireLI means that the vendor must rely on legitimate interest for the purpose, if it is flexible
+	PubRestrictRequireLI uint8 = 2
+)
+
+// PubRestriction is a restriction which the publisher set on a purpose for some vendors
+type PubRestriction struct {
+	PurposeID    uint8
+	RestrictType uint8
+	Vendors      []VendorRange
+}
+
+// VendorRange is a range of vendor IDs. The start and end bounds are inclusive
+type VendorRange struct {
+	StartID uint16
+	EndID   uint16
+}
+
 // IAB spec does not specify a max vendorID for the publisher restrictions. This should be one bit short of the max possible.
 const assumedMaxVendorID uint16 = 32767
 
@@ -71,3 +95,46 @@ func (p *pubRestrictions) CheckPubRestriction(purposeID uint8, restrictType uint
 	return false
 
 }
+
+// PubRestrictions lists every restriction, sorted by purpose and then by restriction type
+func (p *pubRestrictions) PubRestrictions() []PubRestriction {
+	keys := make([]int, 0, len(p.restrictions))
+	for key := range p.restrictions {
+		keys = append(keys, int(key))
+	}
+	// The key is the purpose followed by the restriction type, so this sorts by purpose and then by type
+	sort.Ints(keys)
+
+	restrictions := make([]PubRestriction, 0, len(keys))
+	for _, key := range keys {
+		restriction := p.restrictions[byte(key)]
+		vendors := make([]VendorRange, len(restriction.vendors))
+		for i, vendor := range restriction.vendors {
+			vendors[i] = VendorRange{StartID: vendor.startID, EndID: vendor.endID}
+		}
+		restrictions = append(restrictions, PubRestriction{
+			PurposeID:    restriction.purposeID,
+			RestrictType: restriction.restrictType,
+			Vendors:      vendors,
+		})
+	}
+	return restrictions
+}
+
+// VendorPubRestrictions maps every purpose which the given vendor is restricted on to the restriction type.
+// The spec allows a single restriction type per purpose and vendor. If the consent string sets several anyway,
+// the lowest (and so most restrictive, if PubRestrictNotAllowed is one of them) type is returned.
+func (p *pubRestrictions) VendorPubRestrictions(vendor uint16) map[uint8]uint8 {
+	vendorRestrictions := make(map[uint8]uint8)
+	for _, restriction := range p.restrictions {
+		for i := 0; i < len(restriction.vendors); i++ {
+			if restriction.vendors[i].Contains(vendor) {
+				if restrictType, ok := vendorRestrictions[restriction.purposeID]; !ok || restriction.restrictType < restrictType {
+					vendorRestrictions[restriction.purposeID] = restriction.restrictType
+				}
+				break
+			}
+		}
+	}
+	return vendorRestrictions
+}
@@ -1,6 +1,7 @@
 package vendorconsent
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -54,3 +55,81 @@ func TestPubRestrictions3(t *testing.T) {
 	_, err := Parse(decode(t, "COzSDo9OzSDo9B9AAAENAiCAALAAAAAAAAAACOQAQCOAAAAA"))
 	assertNilError(t, err)
 }
+
+func TestPubRestrictionsEnumeration(t *testing.T) {
+	consent, err := ParseString("COxPe2TOxPe2TALABAENAPCgAAAAAAAAAAAAAFAAAAoAAA4IACACAIABgACAFA4ADACAAIygAGADwAQBIAIAIB0AEAEBSACACAA")
+	assertNilError(t, err)
+
+	expected := []PubRestriction{
+		{PurposeID: 1, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 32, EndID: 32}}},
+		{PurposeID: 2, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 1, EndID: 40}}},
+		{PurposeID: 2, RestrictType: PubRestrictRequireConsent, Vendors: []VendorRange{{StartID: 32, EndID: 32}}},
+		{PurposeID: 7, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 32, EndID: 35}}},
+		{PurposeID: 7, RestrictType: PubRestrictRequireConsent, Vendors: []VendorRange{{StartID: 32, EndID: 32}}},
+		{PurposeID: 10, RestrictType: PubRestrictNotAllowed, Vendors: []VendorRange{{StartID: 30, EndID: 32}}},
+		{PurposeID: 10, RestrictType: PubRestrictRequireConsent, Vendors: []VendorRange{{StartID: 32, EndID: 32}}},
+	}
+	if actual := consent.PubRestrictions(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Publisher restrictions were not equal. Expected %+v, actual %+v", expected, actual)
+	}
+
+	// Every restriction of the enumeration must agree with the point check
+	for _, restriction := range consent.PubRestrictions() {
+		for _, vendors := range restriction.Vendors {
+			for vendor := vendors.StartID; vendor <= vendors.EndID; vendor++ {
+				assertBoolsEqual(t, true, consent.CheckPubRestriction(restriction.PurposeID, restriction.RestrictType, vendor))
+			}
+		}
+	}
+}
+
+func TestPubRestrictionsEnumerationEmpty(t *testing.T) {
+	consent, err := ParseString("COwGVJOOwGVJOADACHENAOCAAO6as_-AAAhoAFNLAAoAAAA")
+	assertNilError(t, err)
+	assertIntsEqual(t, 0, len(consent.PubRestrictions()))
+	assertIntsEqual(t, 0, len(consent.VendorPubRestrictions(1)))
+}
+
+func TestVendorPubRestrictions(t *testing.T) {
+	consent, err := ParseString("COwAdDhOwAdDhN4ABAENAPCgAAQAAv___wAAAFP_AAp_4AI6ACACAA")
+	assertNilError(t, err)
+	tests := []struct {
+		name     string
+		vendor   uint16
+		expected map[uint8]uint8
+	}{
+		{
+			name:     "restricted_vendor",
+			vendor:   32,
+			expected: map[uint8]uint8{7: PubRestrictRequireConsent},
+		},
+		{
+			name:     "unrestricted_vendor",
+			vendor:   7,
+			expected: map[uint8]uint8{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := consent.VendorPubRestrictions(tt.vendor)
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("Vendor restrictions were not equal. Expected %v, actual %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestVendorPubRestrictionsConflictingTypes(t *testing.T) {
+	// Purposes 2, 7 and 10 set both type 0 and type 1 for vendor 32. The most restrictive type wins.
+	consent, err := ParseString("COxPe2TOxPe2TALABAENAPCgAAAAAAAAAAAAAFAAAAoAAA4IACACAIABgACAFA4ADACAAIygAGADwAQBIAIAIB0AEAEBSACACAA")
+	assertNilError(t, err)
+
+	expected := map[uint8]uint8{1: PubRestrictNotAllowed, 2: PubRestrictNotAllowed, 7: PubRestrictNotAllowed, 10: PubRestrictNotAllowed}
+	if actual := consent.VendorPubRestrictions(32); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Vendor restrictions were not equal. Expected %v, actual %v", expected, actual)
+	}
+	expected = map[uint8]uint8{2: PubRestrictNotAllowed, 7: PubRestrictNotAllowed}
+	if actual := consent.VendorPubRestrictions(33); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Vendor restrictions were not equal. Expected %v, actual %v", expected, actual)
+	}
+}
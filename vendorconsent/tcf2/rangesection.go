@@ -2,17 +2,12 @@ package vendorconsent
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/prebid/go-gdpr/bitutils"
 )
 
-func parseRangeSection(metadata ConsentMetadata, maxVendorID uint16, startbit uint) (*rangeSection, uint, error) {
-	data := metadata.data
-
-	if len(data) < 31 {
-		return nil, 0, fmt.Errorf("vendor consent strings using RangeSections require at least 31 bytes. Got %d", len(data))
-	}
-
+func parseRangeSection(data []byte, maxVendorID uint16, startbit uint) (*rangeSection, uint, error) {
 	// This makes an int from bits [startBit, startBit + 12)
 	numEntries, err := bitutils.ParseUInt12(data, startbit)
 	if err != nil {
@@ -110,6 +105,39 @@ func (p *rangeSection) VendorConsent(id uint16) bool {
 	return false
 }
 
+// VendorIDs returns the IDs of every vendor in the RangeSection, in ascending order and without duplicates
+func (p *rangeSection) VendorIDs() []uint16 {
+	if p == nil || len(p.consents) == 0 {
+		return nil
+	}
+
+	// The entries may overlap, and aren't required to be sorted
+	consents := make([]rangeConsent, len(p.consents))
+	copy(consents, p.consents)
+	sort.Slice(consents, func(i, j int) bool {
+		return consents[i].startID < consents[j].startID
+	})
+
+	var ids []uint16
+	for _, consent := range consents {
+		start := consent.startID
+		if len(ids) > 0 && ids[len(ids)-1] >= start {
+			if ids[len(ids)-1] >= consent.endID {
+				continue
+			}
+			start = ids[len(ids)-1] + 1
+		}
+		// Careful with the loop condition, since endID may be the largest uint16
+		for id := start; ; id++ {
+			ids = append(ids, id)
+			if id == consent.endID {
+				break
+			}
+		}
+	}
+	return ids
+}
+
 // This is a RangeSection exception for a range of IDs.
 // The start and end bounds here are inclusive.
 type rangeConsent struct {
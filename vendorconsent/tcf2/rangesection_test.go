@@ -54,3 +54,27 @@ func TestInvalidRangeEdgeCase(t *testing.T) {
 	data = data[:31]
 	assertInvalidBytes(t, data[:31], "ParseUInt16 expected a 16-bit int to start at bit 243, but the consent string was only 31 bytes long")
 }
+
+func TestRangeSectionVendorIDs(t *testing.T) {
+	consent, err := Parse(decode(t, "COyfVVoOyfVVoADACHENAwCAAAAAAAAAAAAAE5QBgALgAqgD8AQACSwEygJyAnSAMABgAFkAgQCDASeAmYBOgAA"))
+	assertNilError(t, err)
+	assertUInt16SlicesEqual(t, []uint16{23, 42, 126, 127, 128, 587, 613, 626}, consent.VendorConsentIDs())
+	assertUInt16SlicesEqual(t, []uint16{24, 44, 129, 130, 131, 591, 614, 628}, consent.VendorLegitInterestIDs())
+}
+
+func TestRangeSectionVendorIDsOverlappingEntries(t *testing.T) {
+	section := &rangeSection{
+		consents: []rangeConsent{
+			{startID: 10, endID: 12},
+			{startID: 2, endID: 2},
+			{startID: 11, endID: 14},
+			{startID: 12, endID: 12},
+			{startID: 65534, endID: 65535},
+		},
+		maxVendorID: 65535,
+	}
+	assertUInt16SlicesEqual(t, []uint16{2, 10, 11, 12, 13, 14, 65534, 65535}, section.VendorIDs())
+
+	empty := &rangeSection{maxVendorID: 10}
+	assertIntsEqual(t, 0, len(empty.VendorIDs()))
+}
@@ -0,0 +1,87 @@
+package vendorconsent
+
+import (
+	"fmt"
+
+	"github.com/prebid/go-gdpr/bitutils"
+)
+
+// The SegmentType is stored in the first 3 bits of every segment which follows the core string.
+const (
+	segmentTypeCore             uint8 = 0
+	segmentTypeDisclosedVendors uint8 = 1
+	segmentTypeAllowedVendors   uint8 = 2
+	segmentTypePublisherTC      uint8 = 3
+)
+
+// parseSegments parses the optional segments which follow the core string, and stores them in the metadata.
+// Segments of a type which this package doesn't know are ignored.
+func parseSegments(metadata *ConsentMetadata, segments []string) error {
+	for i, segment := range segments {
+		data, err := decodeSegment(segment)
+		if err != nil {
+			return fmt.Errorf("Error on decoding segment %d: %s", i+1, err.Error())
+		}
+		if len(data) == 0 {
+			return fmt.Errorf("segment %d is empty", i+1)
+		}
+
+		switch segmentType := data[0] >> 5; segmentType {
+		case segmentTypeCore:
+			return fmt.Errorf("segment %d is a core segment, but the core segment must only appear once at the start of the consent string", i+1)
+		case segmentTypeDisclosedVendors:
+			if metadata.disclosedVendors != nil {
+				return fmt.Errorf("segment %d is a duplicate Disclosed Vendors segment", i+1)
+			}
+			disclosedVendors, err := parseVendorsSegment(data)
+			if err != nil {
+				return fmt.Errorf("Error on parsing the Disclosed Vendors segment: %s", err.Error())
+			}
+			metadata.disclosedVendors = disclosedVendors
+		case segmentTypeAllowedVendors:
+			if metadata.allowedVendors != nil {
+				return fmt.Errorf("segment %d is a duplicate Allowed Vendors segment", i+1)
+			}
+			allowedVendors, err := parseVendorsSegment(data)
+			if err != nil {
+				return fmt.Errorf("Error on parsing the Allowed Vendors segment: %s", err.Error())
+			}
+			metadata.allowedVendors = allowedVendors
+		case segmentTypePublisherTC:
+			if metadata.publisherTC != nil {
+				return fmt.Errorf("segment %d is a duplicate Publisher TC segment", i+1)
+			}
+			publisherTC, err := parsePublisherTC(data)
+			if err != nil {
+				return fmt.Errorf("Error on parsing the Publisher TC segment (segment %d): %s", i+1, err.Error())
+			}
+			metadata.publisherTC = publisherTC
+		}
+	}
+	return nil
+}
+
+// parseVendorsSegment parses a segment which holds a list of vendors, such as the Disclosed Vendors
+// or the Allowed Vendors segment. These segments are made of the SegmentType (bits 0-2), the MaxVendorId (bits 3-18), the IsRangeEncoding
+// flag (bit 19) and then either a BitField or a RangeSection starting at bit 20.
+func parseVendorsSegment(data []byte) (vendorConsentsResolver, error) {
+	maxVendorID, err := bitutils.ParseUInt16(data, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	// ParseUInt16 already made sure that the first 3 bytes, which hold bit 19, are available.
+	if isSet(data, 19) {
+		vendors, _, err := parseRangeSection(data, maxVendorID, 20)
+		if err != nil {
+			return nil, err
+		}
+		return vendors, nil
+	}
+
+	vendors, _, err := parseBitField(data, maxVendorID, 20)
+	if err != nil {
+		return nil, err
+	}
+	return vendors, nil
+}
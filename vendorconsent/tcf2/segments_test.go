@@ -0,0 +1,144 @@
+package vendorconsent
+
+import (
+	"testing"
+)
+
+const coreString = "CPtGDMAPtGDMALMAAAENA_C_AAAAAAAAACiQAAAAAAAA"
+
+func TestDisclosedVendorsBitField(t *testing.T) {
+	// Disclosed Vendors segment with MaxVendorId 10, encoded as a BitField with vendors 1, 3 and 10
+	consent, err := ParseString(coreString + ".IAFKBA")
+	assertNilError(t, err)
+	assertBoolsEqual(t, true, consent.HasDisclosedVendorsSegment())
+	assertUInt16sEqual(t, 10, consent.DisclosedVendorMaxID())
+
+	vendorsDisclosed := buildMap(1, 3, 10)
+	for i := uint16(0); i <= 11; i++ {
+		_, ok := vendorsDisclosed[uint(i)]
+		assertBoolsEqual(t, ok, consent.VendorDisclosed(i))
+	}
+}
+
+func TestDisclosedVendorsRangeSection(t *testing.T) {
+	// Disclosed Vendors segment with MaxVendorId 700, encoded as a RangeSection with vendors 5, 100-200 and 700
+	consent, err := ParseString(coreString + ".IFeQAwACwBkAMgBXgA")
+	assertNilError(t, err)
+	assertBoolsEqual(t, true, consent.HasDisclosedVendorsSegment())
+	assertUInt16sEqual(t, 700, consent.DisclosedVendorMaxID())
+
+	for i := uint16(1); i <= 701; i++ {
+		expected := i == 5 || (i >= 100 && i <= 200) || i == 700
+		assertBoolsEqual(t, expected, consent.VendorDisclosed(i))
+	}
+}
+
+func TestNoDisclosedVendors(t *testing.T) {
+	consent, err := ParseString(coreString)
+	assertNilError(t, err)
+	assertBoolsEqual(t, false, consent.HasDisclosedVendorsSegment())
+	assertUInt16sEqual(t, 0, consent.DisclosedVendorMaxID())
+	assertBoolsEqual(t, false, consent.VendorDisclosed(1))
+}
+
+func TestAllowedVendorsBitField(t *testing.T) {
+	// Allowed Vendors segment with MaxVendorId 10, encoded as a BitField with vendors 2 and 4
+	consent, err := ParseString(coreString + ".QAFFAA")
+	assertNilError(t, err)
+	assertBoolsEqual(t, true, consent.HasAllowedVendorsSegment())
+	assertBoolsEqual(t, false, consent.HasDisclosedVendorsSegment())
+	assertUInt16sEqual(t, 10, consent.AllowedVendorMaxID())
+
+	vendorsAllowed := buildMap(2, 4)
+	for i := uint16(0); i <= 11; i++ {
+		_, ok := vendorsAllowed[uint(i)]
+		assertBoolsEqual(t, ok, consent.VendorAllowedOOB(i))
+	}
+}
+
+func TestAllowedVendorsRangeSection(t *testing.T) {
+	// Disclosed Vendors segment followed by an Allowed Vendors segment with MaxVendorId 300,
+	// encoded as a RangeSection with vendors 10-20 and 300
+	consent, err := ParseString(coreString + ".IAFKBA.QCWQAoAFAAoASwA")
+	assertNilError(t, err)
+	assertBoolsEqual(t, true, consent.HasAllowedVendorsSegment())
+	assertBoolsEqual(t, true, consent.HasDisclosedVendorsSegment())
+	assertUInt16sEqual(t, 300, consent.AllowedVendorMaxID())
+
+	for i := uint16(1); i <= 301; i++ {
+		expected := (i >= 10 && i <= 20) || i == 300
+		assertBoolsEqual(t, expected, consent.VendorAllowedOOB(i))
+	}
+	// The segments must not bleed into each other
+	assertBoolsEqual(t, true, consent.VendorDisclosed(3))
+	assertBoolsEqual(t, false, consent.VendorAllowedOOB(3))
+}
+
+func TestNoAllowedVendors(t *testing.T) {
+	consent, err := ParseString(coreString + ".IAFKBA")
+	assertNilError(t, err)
+	assertBoolsEqual(t, false, consent.HasAllowedVendorsSegment())
+	assertUInt16sEqual(t, 0, consent.AllowedVendorMaxID())
+	assertBoolsEqual(t, false, consent.VendorAllowedOOB(1))
+}
+
+func TestInvalidSegments(t *testing.T) {
+	tests := []struct {
+		name        string
+		consent     string
+		expectError string
+	}{
+		{
+			name:        "empty_segment",
+			consent:     coreString + ".",
+			expectError: "segment 1 is empty",
+		},
+		{
+			name:        "bad_base64",
+			consent:     coreString + ".IAF!BA",
+			expectError: "Error on decoding segment 1: illegal base64 data at input byte 3",
+		},
+		{
+			name:        "second_core_segment",
+			consent:     coreString + ".AAFIAA",
+			expectError: "segment 1 is a core segment, but the core segment must only appear once at the start of the consent string",
+		},
+		{
+			name:        "duplicate_disclosed_vendors",
+			consent:     coreString + ".IAFKBA.IAFKBA",
+			expectError: "segment 2 is a duplicate Disclosed Vendors segment",
+		},
+		{
+			name:        "disclosed_vendors_too_short_for_max_vendor_id",
+			consent:     coreString + ".IA",
+			expectError: "Error on parsing the Disclosed Vendors segment: ParseUInt16 expected a 16-bit int to start at bit 3, but the consent string was only 1 bytes long",
+		},
+		{
+			name:        "disclosed_vendors_bitfield_too_short",
+			consent:     coreString + ".IAFI",
+			expectError: "Error on parsing the Disclosed Vendors segment: a BitField for 10 vendors requires a consent string of 4 bytes. This consent string had 3",
+		},
+		{
+			name:        "disclosed_vendors_range_out_of_bounds",
+			consent:     coreString + ".IAFQAQAFgA",
+			expectError: "Error on parsing the Disclosed Vendors segment: bit 32 range entry excludes vendor 11, but only vendors [1, 10] are valid",
+		},
+		{
+			name:        "duplicate_allowed_vendors",
+			consent:     coreString + ".QAFFAA.IAFKBA.QAFFAA",
+			expectError: "segment 3 is a duplicate Allowed Vendors segment",
+		},
+		{
+			name:        "allowed_vendors_invalid_range",
+			consent:     coreString + ".QAFQAYACgAGA",
+			expectError: "Error on parsing the Allowed Vendors segment: bit 32 range entry excludes vendors [5, 3]. The start should be less than the end",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseString(tt.consent)
+			assertError(t, err)
+			assertStringsEqual(t, tt.expectError, err.Error())
+		})
+	}
+}
@@ -3,6 +3,9 @@ package vendorlist2
 import (
 	"encoding/json"
 	"errors"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/prebid/go-gdpr/api"
 	"github.com/prebid/go-gdpr/consentconstants"
@@ -27,18 +30,54 @@ func ParseEagerly(data []byte) (api.VendorList, error) {
 	}
 
 	parsedList := parsedVendorList{
-		specVersion: contract.GVLSpecificationVersion,
-		version:     contract.Version,
-		vendors:     make(map[uint16]parsedVendor, len(contract.Vendors)),
+		specVersion:    contract.GVLSpecificationVersion,
+		version:        contract.Version,
+		vendors:        make(map[uint16]parsedVendor, len(contract.Vendors)),
+		dataCategories: make(map[uint8]api.DataCategory, len(contract.DataCategories)),
+
+		purposeDefinitions:        mapifyDefinitions(contract.Purposes),
+		specialPurposeDefinitions: mapifyDefinitions(contract.SpecialPurposes),
+		featureDefinitions:        mapifyDefinitions(contract.Features),
+		specialFeatureDefinitions: mapifyDefinitions(contract.SpecialFeatures),
+		stacks:                    make(map[uint8]api.Stack, len(contract.Stacks)),
 	}
 
 	for _, v := range contract.Vendors {
 		parsedList.vendors[v.ID] = parseVendor(v)
 	}
+	for _, category := range contract.DataCategories {
+		parsedList.dataCategories[category.ID] = category
+	}
+	for _, stack := range contract.Stacks {
+		parsedList.stacks[stack.ID] = stack
+	}
 
 	return parsedList, nil
 }
 
+// parseDeletedDate returns the zero time if the deletedDate is missing or malformed, like lazyVendor.DeletedDate
+func parseDeletedDate(data json.RawMessage) time.Time {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return time.Time{}
+	}
+	deletedDate, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return deletedDate
+}
+
+// parseCookieMaxAgeSeconds returns 0 if the cookieMaxAgeSeconds is missing or isn't an integer,
+// like lazyVendor.CookieMaxAgeSeconds
+func parseCookieMaxAgeSeconds(data json.RawMessage) int64 {
+	value, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
 func parseVendor(contract vendorListVendorContract) parsedVendor {
 	parsed := parsedVendor{
 		purposes:            mapifyPurpose(contract.Purposes),
@@ -46,6 +85,26 @@ func parseVendor(contract vendorListVendorContract) parsedVendor {
 		flexiblePurposes:    mapifyPurpose(contract.FlexiblePurposes),
 		specialPurposes:     mapifyPurpose(contract.SpecialPurposes),
 		specialFeatures:     mapifySpecialFeature(contract.SpecialFeatures),
+
+		name:                       contract.Name,
+		policyURL:                  contract.PolicyURL,
+		deletedDate:                parseDeletedDate(contract.DeletedDate),
+		usesCookies:                contract.UsesCookies,
+		cookieMaxAgeSeconds:        parseCookieMaxAgeSeconds(contract.CookieMaxAgeSeconds),
+		cookieRefresh:              contract.CookieRefresh,
+		usesNonCookieAccess:        contract.UsesNonCookieAccess,
+		deviceStorageDisclosureURL: contract.DeviceStorageDisclosureURL,
+
+		urls:            contract.URLs,
+		dataDeclaration: contract.DataDeclaration,
+	}
+	if contract.DataRetention != nil {
+		if contract.DataRetention.StdRetention != nil {
+			parsed.stdRetention = *contract.DataRetention.StdRetention
+			parsed.hasStdRetention = true
+		}
+		parsed.purposeRetention = contract.DataRetention.Purposes
+		parsed.specialPurposeRetention = contract.DataRetention.SpecialPurposes
 	}
 
 	return parsed
@@ -69,10 +128,44 @@ func mapifySpecialFeature(input []uint8) map[consentconstants.SpecialFeature]str
 	return m
 }
 
+func mapifyDefinitions(input map[string]api.Definition) definitions {
+	m := make(definitions, len(input))
+	for _, definition := range input {
+		m[definition.ID] = definition
+	}
+	return m
+}
+
+// definitions maps the IDs of purposes, special purposes, features or special features to their definition
+type definitions map[uint8]api.Definition
+
+func (d definitions) get(id uint8) (api.Definition, bool) {
+	definition, ok := d[id]
+	return definition, ok
+}
+
+func (d definitions) sorted() []api.Definition {
+	sorted := make([]api.Definition, 0, len(d))
+	for _, definition := range d {
+		sorted = append(sorted, definition)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
 type parsedVendorList struct {
-	specVersion uint16
-	version     uint16
-	vendors     map[uint16]parsedVendor
+	specVersion    uint16
+	version        uint16
+	vendors        map[uint16]parsedVendor
+	dataCategories map[uint8]api.DataCategory
+
+	purposeDefinitions        definitions
+	specialPurposeDefinitions definitions
+	featureDefinitions        definitions
+	specialFeatureDefinitions definitions
+	stacks                    map[uint8]api.Stack
 }
 
 func (l parsedVendorList) SpecVersion() uint16 {
@@ -91,12 +184,104 @@ func (l parsedVendorList) Vendor(vendorID uint16) api.Vendor {
 	return nil
 }
 
+// DataCategory returns the data category with the given ID
+func (l parsedVendorList) DataCategory(id uint8) (api.DataCategory, bool) {
+	category, ok := l.dataCategories[id]
+	return category, ok
+}
+
+// DataCategories returns every data category, sorted by ID
+func (l parsedVendorList) DataCategories() []api.DataCategory {
+	categories := make([]api.DataCategory, 0, len(l.dataCategories))
+	for _, category := range l.dataCategories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].ID < categories[j].ID
+	})
+	return categories
+}
+
+// Purpose returns the definition of the purpose
+func (l parsedVendorList) Purpose(id consentconstants.Purpose) (api.Definition, bool) {
+	return l.purposeDefinitions.get(uint8(id))
+}
+
+// Purposes returns the definitions of every purpose, sorted by ID
+func (l parsedVendorList) Purposes() []api.Definition {
+	return l.purposeDefinitions.sorted()
+}
+
+// SpecialPurpose returns the definition of the special purpose
+func (l parsedVendorList) SpecialPurpose(id consentconstants.Purpose) (api.Definition, bool) {
+	return l.specialPurposeDefinitions.get(uint8(id))
+}
+
+// SpecialPurposes returns the definitions of every special purpose, sorted by ID
+func (l parsedVendorList) SpecialPurposes() []api.Definition {
+	return l.specialPurposeDefinitions.sorted()
+}
+
+// Feature returns the definition of the feature
+func (l parsedVendorList) Feature(id uint8) (api.Definition, bool) {
+	return l.featureDefinitions.get(id)
+}
+
+// Features returns the definitions of every feature, sorted by ID
+func (l parsedVendorList) Features() []api.Definition {
+	return l.featureDefinitions.sorted()
+}
+
+// SpecialFeature returns the definition of the special feature
+func (l parsedVendorList) SpecialFeature(id consentconstants.SpecialFeature) (api.Definition, bool) {
+	return l.specialFeatureDefinitions.get(uint8(id))
+}
+
+// SpecialFeatures returns the definitions of every special feature, sorted by ID
+func (l parsedVendorList) SpecialFeatures() []api.Definition {
+	return l.specialFeatureDefinitions.sorted()
+}
+
+// Stack returns the stack with the given ID
+func (l parsedVendorList) Stack(id uint8) (api.Stack, bool) {
+	stack, ok := l.stacks[id]
+	return stack, ok
+}
+
+// Stacks returns every stack, sorted by ID
+func (l parsedVendorList) Stacks() []api.Stack {
+	stacks := make([]api.Stack, 0, len(l.stacks))
+	for _, stack := range l.stacks {
+		stacks = append(stacks, stack)
+	}
+	sort.Slice(stacks, func(i, j int) bool {
+		return stacks[i].ID < stacks[j].ID
+	})
+	return stacks
+}
+
 type parsedVendor struct {
 	purposes            map[consentconstants.Purpose]struct{}
 	legitimateInterests map[consentconstants.Purpose]struct{}
 	flexiblePurposes    map[consentconstants.Purpose]struct{}
 	specialPurposes     map[consentconstants.Purpose]struct{}
 	specialFeatures     map[consentconstants.SpecialFeature]struct{}
+
+	name                       string
+	policyURL                  string
+	deletedDate                time.Time
+	usesCookies                bool
+	cookieMaxAgeSeconds        int64
+	cookieRefresh              bool
+	usesNonCookieAccess        bool
+	deviceStorageDisclosureURL string
+
+	stdRetention            int
+	hasStdRetention         bool
+	purposeRetention        map[consentconstants.Purpose]int
+	specialPurposeRetention map[consentconstants.Purpose]int
+	urls                    []api.VendorURL
+	dataDeclaration         []uint8
 }
 
 func (l parsedVendor) Purpose(purposeID consentconstants.Purpose) (hasPurpose bool) {
@@ -143,10 +328,87 @@ func (l parsedVendor) SpecialFeature(featureID consentconstants.SpecialFeature)
 	return
 }
 
+// Name returns the name of the vendor
+func (l parsedVendor) Name() string {
+	return l.name
+}
+
+// PolicyURL returns the URL of the vendor's privacy policy
+func (l parsedVendor) PolicyURL() string {
+	return l.policyURL
+}
+
+// DeletedDate returns the time when the vendor was deleted from the vendor list, or the zero time if it wasn't
+func (l parsedVendor) DeletedDate() time.Time {
+	return l.deletedDate
+}
+
+// UsesCookies returns true if the vendor stores information on devices with cookies
+func (l parsedVendor) UsesCookies() bool {
+	return l.usesCookies
+}
+
+// CookieMaxAgeSeconds returns the longest duration, in seconds, of the cookies which the vendor sets
+func (l parsedVendor) CookieMaxAgeSeconds() int64 {
+	return l.cookieMaxAgeSeconds
+}
+
+// CookieRefresh returns true if the vendor refreshes the duration of its cookies
+func (l parsedVendor) CookieRefresh() bool {
+	return l.cookieRefresh
+}
+
+// UsesNonCookieAccess returns true if the vendor stores or accesses information on devices without cookies
+func (l parsedVendor) UsesNonCookieAccess() bool {
+	return l.usesNonCookieAccess
+}
+
+// DeviceStorageDisclosureURL returns the URL of the vendor's device storage disclosure
+func (l parsedVendor) DeviceStorageDisclosureURL() string {
+	return l.deviceStorageDisclosureURL
+}
+
+// StdRetention returns the number of days for which the vendor keeps personal data by default
+func (l parsedVendor) StdRetention() (days int, ok bool) {
+	return l.stdRetention, l.hasStdRetention
+}
+
+// PurposeRetention returns the number of days for which the vendor keeps personal data for the purpose
+func (l parsedVendor) PurposeRetention(purposeID consentconstants.Purpose) (days int, ok bool) {
+	if days, ok := l.purposeRetention[purposeID]; ok {
+		return days, true
+	}
+	return l.StdRetention()
+}
+
+// SpecialPurposeRetention returns the number of days for which the vendor keeps personal data for the special purpose
+func (l parsedVendor) SpecialPurposeRetention(purposeID consentconstants.Purpose) (days int, ok bool) {
+	if days, ok := l.specialPurposeRetention[purposeID]; ok {
+		return days, true
+	}
+	return l.StdRetention()
+}
+
+// URLs returns the vendor's URLs in every language which it provides
+func (l parsedVendor) URLs() []api.VendorURL {
+	return append([]api.VendorURL(nil), l.urls...)
+}
+
+// DataDeclaration returns the IDs of the data categories which the vendor collects
+func (l parsedVendor) DataDeclaration() []uint8 {
+	return append([]uint8(nil), l.dataDeclaration...)
+}
+
 type vendorListContract struct {
 	GVLSpecificationVersion uint16                              `json:"gvlSpecificationVersion"`
 	Version                 uint16                              `json:"vendorListVersion"`
 	Vendors                 map[string]vendorListVendorContract `json:"vendors"`
+	DataCategories          map[string]api.DataCategory         `json:"dataCategories"`
+	Purposes                map[string]api.Definition           `json:"purposes"`
+	SpecialPurposes         map[string]api.Definition           `json:"specialPurposes"`
+	Features                map[string]api.Definition           `json:"features"`
+	SpecialFeatures         map[string]api.Definition           `json:"specialFeatures"`
+	Stacks                  map[string]api.Stack                `json:"stacks"`
 }
 
 type vendorListVendorContract struct {
@@ -156,4 +418,24 @@ type vendorListVendorContract struct {
 	FlexiblePurposes    []uint8 `json:"flexiblePurposes"`
 	SpecialPurposes     []uint8 `json:"specialPurposes"`
 	SpecialFeatures     []uint8 `json:"specialFeatures"`
+
+	// DeletedDate and CookieMaxAgeSeconds are decoded by parseVendor, so that a malformed value only loses that value
+	Name                       string          `json:"name"`
+	PolicyURL                  string          `json:"policyUrl"`
+	DeletedDate                json.RawMessage `json:"deletedDate"`
+	UsesCookies                bool            `json:"usesCookies"`
+	CookieMaxAgeSeconds        json.RawMessage `json:"cookieMaxAgeSeconds"`
+	CookieRefresh              bool            `json:"cookieRefresh"`
+	UsesNonCookieAccess        bool            `json:"usesNonCookieAccess"`
+	DeviceStorageDisclosureURL string          `json:"deviceStorageDisclosureUrl"`
+
+	DataRetention   *dataRetentionContract `json:"dataRetention"`
+	URLs            []api.VendorURL        `json:"urls"`
+	DataDeclaration []uint8                `json:"dataDeclaration"`
+}
+
+type dataRetentionContract struct {
+	StdRetention    *int                             `json:"stdRetention"`
+	Purposes        map[consentconstants.Purpose]int `json:"purposes"`
+	SpecialPurposes map[consentconstants.Purpose]int `json:"specialPurposes"`
 }
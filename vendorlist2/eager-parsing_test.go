@@ -3,6 +3,7 @@ package vendorlist2
 import (
 	"testing"
 
+	"github.com/prebid/go-gdpr/api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,6 +37,7 @@ func TestParseEagerlyVendorList(t *testing.T) {
 			assert.NotNil(t, parsedGVL.Vendor(8))
 			assert.NotNil(t, parsedGVL.Vendor(80))
 			AssertVendorListCorrectness(t, parsedGVL)
+			AssertVendorMetadataCorrectness(t, parsedGVL)
 		})
 	}
 }
@@ -72,3 +74,40 @@ func TestParseEagerlyEmptyVendorList(t *testing.T) {
 		})
 	}
 }
+
+func TestParseEagerlyMalformedMetadata(t *testing.T) {
+	parsedGVL, err := ParseEagerly([]byte(testDataMalformedMetadata))
+	assert.NoError(t, err)
+	AssertMalformedMetadataIgnored(t, parsedGVL)
+}
+
+func TestParseEagerlyDataDeclaration(t *testing.T) {
+	parsedGVL, err := ParseEagerly([]byte(testDataSpecVersion3))
+	assert.NoError(t, err)
+	AssertVendorDataDeclarationCorrectness(t, parsedGVL)
+
+	// Vendor lists of specification version 2 don't declare anything
+	parsedGVL, err = ParseEagerly([]byte(testDataSpecVersion2))
+	assert.NoError(t, err)
+	vendor := parsedGVL.Vendor(8).(api.VendorDataDeclaration)
+	_, ok := vendor.PurposeRetention(1)
+	assert.False(t, ok)
+	assert.Empty(t, vendor.URLs())
+	assert.Empty(t, vendor.DataDeclaration())
+	assert.Empty(t, parsedGVL.(api.VendorListDataCategories).DataCategories())
+}
+
+func TestParseEagerlyDefinitions(t *testing.T) {
+	parsedGVL, err := ParseEagerly([]byte(testDataSpecVersion3))
+	assert.NoError(t, err)
+	AssertDefinitionsCorrectness(t, parsedGVL)
+
+	// Vendor lists of specification version 2 have no illustrations
+	parsedGVL, err = ParseEagerly([]byte(testDataSpecVersion2))
+	assert.NoError(t, err)
+	purpose, ok := parsedGVL.(api.VendorListDefinitions).Purpose(1)
+	assert.True(t, ok)
+	assert.Equal(t, "Store and/or access information on a device", purpose.Name)
+	assert.Empty(t, purpose.Illustrations)
+	assert.Empty(t, parsedGVL.(api.VendorListDefinitions).Features())
+}
@@ -1,7 +1,9 @@
 package vendorlist2
 
 import (
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/buger/jsonparser"
 	"github.com/prebid/go-gdpr/api"
@@ -44,6 +46,157 @@ func (l lazyVendorList) Vendor(vendorID uint16) api.Vendor {
 	return nil
 }
 
+// DataCategory returns the data category with the given ID
+func (l lazyVendorList) DataCategory(id uint8) (api.DataCategory, bool) {
+	categoryBytes, dataType, _, err := jsonparser.Get(l, "dataCategories", strconv.Itoa(int(id)))
+	if err != nil || dataType != jsonparser.Object {
+		return api.DataCategory{}, false
+	}
+	return lazyDataCategory(id, categoryBytes), true
+}
+
+// DataCategories returns every data category, sorted by ID
+func (l lazyVendorList) DataCategories() []api.DataCategory {
+	var categories []api.DataCategory
+	jsonparser.ObjectEach(l, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
+		if id, err := strconv.ParseUint(string(key), 10, 8); err == nil && dataType == jsonparser.Object {
+			categories = append(categories, lazyDataCategory(uint8(id), value))
+		}
+		return nil
+	}, "dataCategories")
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].ID < categories[j].ID
+	})
+	return categories
+}
+
+func lazyDataCategory(id uint8, data []byte) api.DataCategory {
+	return api.DataCategory{
+		ID:          id,
+		Name:        lazyParseString(data, "name"),
+		Description: lazyParseString(data, "description"),
+	}
+}
+
+// Purpose returns the definition of the purpose
+func (l lazyVendorList) Purpose(id consentconstants.Purpose) (api.Definition, bool) {
+	return lazyParseDefinition(l, "purposes", uint8(id))
+}
+
+// Purposes returns the definitions of every purpose, sorted by ID
+func (l lazyVendorList) Purposes() []api.Definition {
+	return lazyParseDefinitions(l, "purposes")
+}
+
+// SpecialPurpose returns the definition of the special purpose
+func (l lazyVendorList) SpecialPurpose(id consentconstants.Purpose) (api.Definition, bool) {
+	return lazyParseDefinition(l, "specialPurposes", uint8(id))
+}
+
+// SpecialPurposes returns the definitions of every special purpose, sorted by ID
+func (l lazyVendorList) SpecialPurposes() []api.Definition {
+	return lazyParseDefinitions(l, "specialPurposes")
+}
+
+// Feature returns the definition of the feature
+func (l lazyVendorList) Feature(id uint8) (api.Definition, bool) {
+	return lazyParseDefinition(l, "features", id)
+}
+
+// Features returns the definitions of every feature, sorted by ID
+func (l lazyVendorList) Features() []api.Definition {
+	return lazyParseDefinitions(l, "features")
+}
+
+// SpecialFeature returns the definition of the special feature
+func (l lazyVendorList) SpecialFeature(id consentconstants.SpecialFeature) (api.Definition, bool) {
+	return lazyParseDefinition(l, "specialFeatures", uint8(id))
+}
+
+// SpecialFeatures returns the definitions of every special feature, sorted by ID
+func (l lazyVendorList) SpecialFeatures() []api.Definition {
+	return lazyParseDefinitions(l, "specialFeatures")
+}
+
+// Stack returns the stack with the given ID
+func (l lazyVendorList) Stack(id uint8) (api.Stack, bool) {
+	stackBytes, dataType, _, err := jsonparser.Get(l, "stacks", strconv.Itoa(int(id)))
+	if err != nil || dataType != jsonparser.Object {
+		return api.Stack{}, false
+	}
+	return lazyStack(id, stackBytes), true
+}
+
+// Stacks returns every stack, sorted by ID
+func (l lazyVendorList) Stacks() []api.Stack {
+	var stacks []api.Stack
+	jsonparser.ObjectEach(l, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
+		if id, err := strconv.ParseUint(string(key), 10, 8); err == nil && dataType == jsonparser.Object {
+			stacks = append(stacks, lazyStack(uint8(id), value))
+		}
+		return nil
+	}, "stacks")
+	sort.Slice(stacks, func(i, j int) bool {
+		return stacks[i].ID < stacks[j].ID
+	})
+	return stacks
+}
+
+func lazyStack(id uint8, data []byte) api.Stack {
+	stack := api.Stack{
+		ID:          id,
+		Name:        lazyParseString(data, "name"),
+		Description: lazyParseString(data, "description"),
+	}
+	for _, purpose := range lazyParseIDs(data, "purposes") {
+		stack.Purposes = append(stack.Purposes, consentconstants.Purpose(purpose))
+	}
+	for _, feature := range lazyParseIDs(data, "specialFeatures") {
+		stack.SpecialFeatures = append(stack.SpecialFeatures, consentconstants.SpecialFeature(feature))
+	}
+	return stack
+}
+
+// lazyParseDefinition returns the definition at "data.key.id"
+func lazyParseDefinition(data []byte, key string, id uint8) (api.Definition, bool) {
+	definitionBytes, dataType, _, err := jsonparser.Get(data, key, strconv.Itoa(int(id)))
+	if err != nil || dataType != jsonparser.Object {
+		return api.Definition{}, false
+	}
+	return lazyDefinition(id, definitionBytes), true
+}
+
+// lazyParseDefinitions returns every definition in the object at "data.key", sorted by ID
+func lazyParseDefinitions(data []byte, key string) []api.Definition {
+	var definitions []api.Definition
+	jsonparser.ObjectEach(data, func(idBytes []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
+		if id, err := strconv.ParseUint(string(idBytes), 10, 8); err == nil && dataType == jsonparser.Object {
+			definitions = append(definitions, lazyDefinition(uint8(id), value))
+		}
+		return nil
+	}, key)
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].ID < definitions[j].ID
+	})
+	return definitions
+}
+
+func lazyDefinition(id uint8, data []byte) api.Definition {
+	definition := api.Definition{
+		ID:          id,
+		Name:        lazyParseString(data, "name"),
+		Description: lazyParseString(data, "description"),
+	}
+	jsonparser.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if err == nil && dataType == jsonparser.String {
+			if illustration, err := jsonparser.ParseString(value); err == nil {
+				definition.Illustrations = append(definition.Illustrations, illustration)
+			}
+		}
+	}, "illustrations")
+	return definition
+}
+
 type lazyVendor []byte
 
 func (l lazyVendor) Purpose(purposeID consentconstants.Purpose) bool {
@@ -82,6 +235,95 @@ func (l lazyVendor) SpecialFeature(featureID consentconstants.SpecialFeature) (h
 	return idExists(l, int(featureID), "specialFeatures")
 }
 
+// Name returns the name of the vendor
+func (l lazyVendor) Name() string {
+	return lazyParseString(l, "name")
+}
+
+// PolicyURL returns the URL of the vendor's privacy policy
+func (l lazyVendor) PolicyURL() string {
+	return lazyParseString(l, "policyUrl")
+}
+
+// DeletedDate returns the time when the vendor was deleted from the vendor list, or the zero time if it wasn't
+// or if the date is malformed
+func (l lazyVendor) DeletedDate() time.Time {
+	deletedDate, err := time.Parse(time.RFC3339, lazyParseString(l, "deletedDate"))
+	if err != nil {
+		return time.Time{}
+	}
+	return deletedDate
+}
+
+// UsesCookies returns true if the vendor stores information on devices with cookies
+func (l lazyVendor) UsesCookies() bool {
+	return lazyParseBool(l, "usesCookies")
+}
+
+// CookieMaxAgeSeconds returns the longest duration, in seconds, of the cookies which the vendor sets
+func (l lazyVendor) CookieMaxAgeSeconds() int64 {
+	if val, err := jsonparser.GetInt(l, "cookieMaxAgeSeconds"); err == nil {
+		return val
+	}
+	return 0
+}
+
+// CookieRefresh returns true if the vendor refreshes the duration of its cookies
+func (l lazyVendor) CookieRefresh() bool {
+	return lazyParseBool(l, "cookieRefresh")
+}
+
+// UsesNonCookieAccess returns true if the vendor stores or accesses information on devices without cookies
+func (l lazyVendor) UsesNonCookieAccess() bool {
+	return lazyParseBool(l, "usesNonCookieAccess")
+}
+
+// DeviceStorageDisclosureURL returns the URL of the vendor's device storage disclosure
+func (l lazyVendor) DeviceStorageDisclosureURL() string {
+	return lazyParseString(l, "deviceStorageDisclosureUrl")
+}
+
+// StdRetention returns the number of days for which the vendor keeps personal data by default
+func (l lazyVendor) StdRetention() (days int, ok bool) {
+	return lazyParseInt(l, "dataRetention", "stdRetention")
+}
+
+// PurposeRetention returns the number of days for which the vendor keeps personal data for the purpose
+func (l lazyVendor) PurposeRetention(purposeID consentconstants.Purpose) (days int, ok bool) {
+	if days, ok := lazyParseInt(l, "dataRetention", "purposes", strconv.Itoa(int(purposeID))); ok {
+		return days, true
+	}
+	return l.StdRetention()
+}
+
+// SpecialPurposeRetention returns the number of days for which the vendor keeps personal data for the special purpose
+func (l lazyVendor) SpecialPurposeRetention(purposeID consentconstants.Purpose) (days int, ok bool) {
+	if days, ok := lazyParseInt(l, "dataRetention", "specialPurposes", strconv.Itoa(int(purposeID))); ok {
+		return days, true
+	}
+	return l.StdRetention()
+}
+
+// URLs returns the vendor's URLs in every language which it provides
+func (l lazyVendor) URLs() []api.VendorURL {
+	var urls []api.VendorURL
+	jsonparser.ArrayEach(l, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if err == nil && dataType == jsonparser.Object {
+			urls = append(urls, api.VendorURL{
+				LangID:      lazyParseString(value, "langId"),
+				Privacy:     lazyParseString(value, "privacy"),
+				LegIntClaim: lazyParseString(value, "legIntClaim"),
+			})
+		}
+	}, "urls")
+	return urls
+}
+
+// DataDeclaration returns the IDs of the data categories which the vendor collects
+func (l lazyVendor) DataDeclaration() []uint8 {
+	return lazyParseIDs(l, "dataDeclaration")
+}
+
 // Returns false unless "id" exists in an array located at "data.key".
 func idExists(data []byte, id int, key string) bool {
 	hasID := false
@@ -99,8 +341,8 @@ func idExists(data []byte, id int, key string) bool {
 	return hasID
 }
 
-func lazyParseInt(data []byte, key string) (int, bool) {
-	if value, dataType, _, err := jsonparser.Get(data, key); err == nil && dataType == jsonparser.Number {
+func lazyParseInt(data []byte, keys ...string) (int, bool) {
+	if value, dataType, _, err := jsonparser.Get(data, keys...); err == nil && dataType == jsonparser.Number {
 		intVal, err := strconv.Atoi(string(value))
 		if err != nil {
 			return 0, false
@@ -109,3 +351,32 @@ func lazyParseInt(data []byte, key string) (int, bool) {
 	}
 	return 0, false
 }
+
+// lazyParseString returns the string at "data.key", or an empty string if it doesn't exist
+func lazyParseString(data []byte, key string) string {
+	if value, err := jsonparser.GetString(data, key); err == nil {
+		return value
+	}
+	return ""
+}
+
+// lazyParseBool returns the boolean at "data.key", or false if it doesn't exist
+func lazyParseBool(data []byte, key string) bool {
+	if value, err := jsonparser.GetBoolean(data, key); err == nil {
+		return value
+	}
+	return false
+}
+
+// lazyParseIDs returns the IDs in the array at "data.key", skipping the ones which don't fit in a uint8
+func lazyParseIDs(data []byte, key string) []uint8 {
+	var ids []uint8
+	jsonparser.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if err == nil && dataType == jsonparser.Number {
+			if id, err := strconv.ParseUint(string(value), 10, 8); err == nil {
+				ids = append(ids, uint8(id))
+			}
+		}
+	}, key)
+	return ids
+}
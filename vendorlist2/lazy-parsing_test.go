@@ -3,6 +3,7 @@ package vendorlist2
 import (
 	"testing"
 
+	"github.com/prebid/go-gdpr/api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,10 +36,15 @@ func TestParseLazilyVendorList(t *testing.T) {
 			assert.NotNil(t, parsedGVL.Vendor(8))
 			assert.NotNil(t, parsedGVL.Vendor(80))
 			AssertVendorListCorrectness(t, parsedGVL)
+			AssertVendorMetadataCorrectness(t, parsedGVL)
 		})
 	}
 }
 
+func TestParseLazilyMalformedMetadata(t *testing.T) {
+	AssertMalformedMetadataIgnored(t, ParseLazily([]byte(testDataMalformedMetadata)))
+}
+
 func TestParseLazilyEmptyVendorList(t *testing.T) {
 	tests := []struct {
 		name                  string
@@ -70,3 +76,30 @@ func TestParseLazilyEmptyVendorList(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLazilyDataDeclaration(t *testing.T) {
+	parsedGVL := ParseLazily([]byte(testDataSpecVersion3))
+	AssertVendorDataDeclarationCorrectness(t, parsedGVL)
+
+	// Vendor lists of specification version 2 don't declare anything
+	parsedGVL = ParseLazily([]byte(testDataSpecVersion2))
+	vendor := parsedGVL.Vendor(8).(api.VendorDataDeclaration)
+	_, ok := vendor.PurposeRetention(1)
+	assert.False(t, ok)
+	assert.Empty(t, vendor.URLs())
+	assert.Empty(t, vendor.DataDeclaration())
+	assert.Empty(t, parsedGVL.(api.VendorListDataCategories).DataCategories())
+}
+
+func TestParseLazilyDefinitions(t *testing.T) {
+	parsedGVL := ParseLazily([]byte(testDataSpecVersion3))
+	AssertDefinitionsCorrectness(t, parsedGVL)
+
+	// Vendor lists of specification version 2 have no illustrations
+	parsedGVL = ParseLazily([]byte(testDataSpecVersion2))
+	purpose, ok := parsedGVL.(api.VendorListDefinitions).Purpose(1)
+	assert.True(t, ok)
+	assert.Equal(t, "Store and/or access information on a device", purpose.Name)
+	assert.Empty(t, purpose.Illustrations)
+	assert.Empty(t, parsedGVL.(api.VendorListDefinitions).Features())
+}
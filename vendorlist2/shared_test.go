@@ -2,8 +2,10 @@ package vendorlist2
 
 import (
 	"testing"
+	"time"
 
 	"github.com/prebid/go-gdpr/api"
+	"github.com/stretchr/testify/assert"
 )
 
 func AssertVendorListCorrectness(t *testing.T, gvl api.VendorList) {
@@ -66,12 +68,178 @@ func AssertVendorListCorrectness(t *testing.T, gvl api.VendorList) {
 	assertBoolsEqual(t, false, v.SpecialFeature(3)) // Does not exist yet
 }
 
+// AssertVendorMetadataCorrectness checks the api.VendorMetadata of the vendors in testDataSpecVersion2 or testDataSpecVersion3
+func AssertVendorMetadataCorrectness(t *testing.T, gvl api.VendorList) {
+	t.Helper()
+	v, ok := gvl.Vendor(8).(api.VendorMetadata)
+	if !ok {
+		t.Fatal("Vendor 8 should implement api.VendorMetadata")
+	}
+	assertStringsEqual(t, "Emerse Sverige AB", v.Name())
+	if gvl.SpecVersion() == 2 {
+		assertStringsEqual(t, "https://www.emerse.com/privacy-policy/", v.PolicyURL())
+	} else {
+		assertStringsEqual(t, "", v.PolicyURL())
+	}
+	assertBoolsEqual(t, true, v.DeletedDate().IsZero())
+	assertBoolsEqual(t, false, v.UsesCookies())
+	assertIntsEqual(t, 0, int(v.CookieMaxAgeSeconds()))
+	assertBoolsEqual(t, false, v.CookieRefresh())
+	assertBoolsEqual(t, true, v.UsesNonCookieAccess())
+	assertStringsEqual(t, "", v.DeviceStorageDisclosureURL())
+
+	v, ok = gvl.Vendor(80).(api.VendorMetadata)
+	if !ok {
+		t.Fatal("Vendor 80 should implement api.VendorMetadata")
+	}
+	assertStringsEqual(t, "Sharethrough, Inc", v.Name())
+	assertBoolsEqual(t, true, v.DeletedDate().Equal(time.Date(2020, time.June, 28, 0, 0, 0, 0, time.UTC)))
+	assertBoolsEqual(t, true, v.UsesCookies())
+	assertIntsEqual(t, 2592000, int(v.CookieMaxAgeSeconds()))
+	assertBoolsEqual(t, true, v.CookieRefresh())
+	assertBoolsEqual(t, false, v.UsesNonCookieAccess())
+	assertStringsEqual(t, "https://platform-cdn.sharethrough.com/device-storage.json", v.DeviceStorageDisclosureURL())
+}
+
+// AssertMalformedMetadataIgnored checks that the malformed values of testDataMalformedMetadata read as zero values,
+// without losing the rest of the vendor list
+func AssertMalformedMetadataIgnored(t *testing.T, gvl api.VendorList) {
+	t.Helper()
+	for _, vendorID := range []uint16{8, 80, 81} {
+		v, ok := gvl.Vendor(vendorID).(api.VendorMetadata)
+		if !ok {
+			t.Fatalf("Vendor %d should implement api.VendorMetadata", vendorID)
+		}
+		assert.True(t, v.DeletedDate().IsZero(), "vendor %d", vendorID)
+		assertIntsEqual(t, 0, int(v.CookieMaxAgeSeconds()))
+		assertBoolsEqual(t, true, v.UsesCookies())
+	}
+	assertBoolsEqual(t, true, gvl.Vendor(80).Purpose(2))
+
+	v := gvl.Vendor(82).(api.VendorMetadata)
+	assert.Equal(t, time.Date(2020, time.June, 28, 0, 0, 0, 0, time.UTC), v.DeletedDate())
+	assertIntsEqual(t, 86400, int(v.CookieMaxAgeSeconds()))
+}
+
+// AssertVendorDataDeclarationCorrectness checks the api.VendorDataDeclaration and api.VendorListDataCategories
+// of testDataSpecVersion3
+func AssertVendorDataDeclarationCorrectness(t *testing.T, gvl api.VendorList) {
+	t.Helper()
+	v, ok := gvl.Vendor(8).(api.VendorDataDeclaration)
+	if !ok {
+		t.Fatal("Vendor 8 should implement api.VendorDataDeclaration")
+	}
+	assertRetentionEqual(t, 30, true, v.StdRetention)
+	assertRetentionEqual(t, 180, true, func() (int, bool) { return v.PurposeRetention(9) })
+	assertRetentionEqual(t, 30, true, func() (int, bool) { return v.PurposeRetention(1) })
+	assertRetentionEqual(t, 30, true, func() (int, bool) { return v.SpecialPurposeRetention(1) })
+	assert.Equal(t, []uint8{1, 2, 4, 6}, v.DataDeclaration())
+	assert.Equal(t, []api.VendorURL{
+		{LangID: "en", Privacy: "https://vendorname.com/gdpr.html", LegIntClaim: "https://vendorname.com/gdpr.html#li"},
+		{LangID: "fr", Privacy: "https://vendorname.com/fr/gdpr.html", LegIntClaim: "https://vendorname.com/fr/gdpr.html#li"},
+	}, v.URLs())
+
+	// Vendor 81 has no standard retention, and declares no legitimate interest claim
+	v, ok = gvl.Vendor(81).(api.VendorDataDeclaration)
+	if !ok {
+		t.Fatal("Vendor 81 should implement api.VendorDataDeclaration")
+	}
+	assertRetentionEqual(t, 0, false, v.StdRetention)
+	assertRetentionEqual(t, 365, true, func() (int, bool) { return v.PurposeRetention(2) })
+	assertRetentionEqual(t, 0, false, func() (int, bool) { return v.PurposeRetention(1) })
+	assertRetentionEqual(t, 90, true, func() (int, bool) { return v.SpecialPurposeRetention(1) })
+	assertRetentionEqual(t, 0, false, func() (int, bool) { return v.SpecialPurposeRetention(2) })
+	assert.Equal(t, []uint8{3}, v.DataDeclaration())
+	assert.Equal(t, []api.VendorURL{{LangID: "en", Privacy: "https://partial.example.com/privacy-policy"}}, v.URLs())
+
+	categories, ok := gvl.(api.VendorListDataCategories)
+	if !ok {
+		t.Fatal("The vendor list should implement api.VendorListDataCategories")
+	}
+	category, ok := categories.DataCategory(3)
+	assertBoolsEqual(t, true, ok)
+	assert.Equal(t, "Device identifiers", category.Name)
+	_, ok = categories.DataCategory(4)
+	assertBoolsEqual(t, false, ok)
+	all := categories.DataCategories()
+	if assert.Len(t, all, 3) {
+		assert.Equal(t, api.DataCategory{
+			ID:          1,
+			Name:        "IP addresses",
+			Description: "Your IP address is a number assigned by your Internet Service Provider to any Internet connection.",
+		}, all[0])
+		assert.Equal(t, uint8(2), all[1].ID)
+		assert.Equal(t, uint8(3), all[2].ID)
+	}
+}
+
+// AssertDefinitionsCorrectness checks the api.VendorListDefinitions of testDataSpecVersion3
+func AssertDefinitionsCorrectness(t *testing.T, gvl api.VendorList) {
+	t.Helper()
+	definitions, ok := gvl.(api.VendorListDefinitions)
+	if !ok {
+		t.Fatal("The vendor list should implement api.VendorListDefinitions")
+	}
+
+	purpose, ok := definitions.Purpose(2)
+	assertBoolsEqual(t, true, ok)
+	assert.Equal(t, api.Definition{
+		ID:          2,
+		Name:        "Use limited data to select advertising",
+		Description: "Advertising presented to you on this service can be based on limited data.",
+		Illustrations: []string{
+			"A car manufacturer wants to promote its electric vehicles to environmentally conscious users.",
+			"A large producer of watercolour paints wants to carry out an online advertising campaign.",
+		},
+	}, purpose)
+	_, ok = definitions.Purpose(3)
+	assertBoolsEqual(t, false, ok)
+	purposes := definitions.Purposes()
+	if assert.Len(t, purposes, 2) {
+		assert.Equal(t, "Store and/or access information on a device", purposes[0].Name)
+		assert.Empty(t, purposes[0].Illustrations)
+		assert.Equal(t, uint8(2), purposes[1].ID)
+	}
+
+	specialPurpose, ok := definitions.SpecialPurpose(1)
+	assertBoolsEqual(t, true, ok)
+	assert.Equal(t, "Ensure security, prevent and detect fraud, and fix errors", specialPurpose.Name)
+	assert.Len(t, definitions.SpecialPurposes(), 1)
+
+	feature, ok := definitions.Feature(1)
+	assertBoolsEqual(t, true, ok)
+	assert.Equal(t, "Match and combine data from other data sources", feature.Name)
+	_, ok = definitions.Feature(2)
+	assertBoolsEqual(t, false, ok)
+	assert.Len(t, definitions.Features(), 1)
+
+	specialFeature, ok := definitions.SpecialFeature(1)
+	assertBoolsEqual(t, true, ok)
+	assert.Equal(t, "Use precise geolocation data", specialFeature.Name)
+	assert.Len(t, definitions.SpecialFeatures(), 1)
+}
+
+func assertRetentionEqual(t *testing.T, expectedDays int, expectedOK bool, retention func() (int, bool)) {
+	t.Helper()
+	days, ok := retention()
+	assertBoolsEqual(t, expectedOK, ok)
+	assertIntsEqual(t, expectedDays, days)
+}
+
 const testDataSpecVersion2 = `
 {
 	"gvlSpecificationVersion": 2,
 	"vendorListVersion": 28,
 	"tcfPolicyVersion": 2,
 	"lastUpdated": "2020-03-05T16:05:29Z",
+	"purposes": {
+		"1": {
+			"id": 1,
+			"name": "Store and/or access information on a device",
+			"description": "Cookies, device identifiers, or other information can be stored or accessed on your device for the purposes presented to you.",
+			"descriptionLegal": "Vendors can store and access information on a device."
+		}
+	},
 	"vendors": {
 		"8": {
 			"id": 8,
@@ -82,7 +250,10 @@ const testDataSpecVersion2 = `
 			"specialPurposes": [1, 2],
 			"features": [1, 2],
 			"specialFeatures": [1, 2],
-			"policyUrl": "https://www.emerse.com/privacy-policy/"
+			"policyUrl": "https://www.emerse.com/privacy-policy/",
+			"usesCookies": false,
+			"cookieMaxAgeSeconds": null,
+			"usesNonCookieAccess": true
 		},
 		"80": {
 			"id": 80,
@@ -93,7 +264,13 @@ const testDataSpecVersion2 = `
 			"specialPurposes": [],
 			"features": [],
 			"specialFeatures": [],
-			"policyUrl": "https://platform-cdn.sharethrough.com/privacy-policy"
+			"policyUrl": "https://platform-cdn.sharethrough.com/privacy-policy",
+			"deletedDate": "2020-06-28T00:00:00Z",
+			"usesCookies": true,
+			"cookieMaxAgeSeconds": 2592000,
+			"cookieRefresh": true,
+			"usesNonCookieAccess": false,
+			"deviceStorageDisclosureUrl": "https://platform-cdn.sharethrough.com/device-storage.json"
 		}
 	}
 }
@@ -115,6 +292,47 @@ const testDataSpecVersion3 = `
 	"vendorListVersion": 1,
 	"tcfPolicyVersion": 4,
 	"lastUpdated": "2023-05-18T16:07:14Z",
+	"purposes": {
+		"2": {
+			"id": 2,
+			"name": "Use limited data to select advertising",
+			"description": "Advertising presented to you on this service can be based on limited data.",
+			"illustrations": [
+				"A car manufacturer wants to promote its electric vehicles to environmentally conscious users.",
+				"A large producer of watercolour paints wants to carry out an online advertising campaign."
+			]
+		},
+		"1": {
+			"id": 1,
+			"name": "Store and/or access information on a device",
+			"description": "Cookies, device or similar online identifiers together with other information can be stored or read on your device.",
+			"illustrations": []
+		}
+	},
+	"specialPurposes": {
+		"1": {
+			"id": 1,
+			"name": "Ensure security, prevent and detect fraud, and fix errors",
+			"description": "Your data can be used to monitor for and prevent unusual and possibly fraudulent activity.",
+			"illustrations": []
+		}
+	},
+	"features": {
+		"1": {
+			"id": 1,
+			"name": "Match and combine data from other data sources",
+			"description": "Information about your activity on this service may be matched and combined with other information.",
+			"illustrations": []
+		}
+	},
+	"specialFeatures": {
+		"1": {
+			"id": 1,
+			"name": "Use precise geolocation data",
+			"description": "With your acceptance, your precise location (within a radius of less than 500 metres) may be used.",
+			"illustrations": []
+		}
+	},
 	"vendors": {
 		"8": {
 			"id": 8,
@@ -125,6 +343,9 @@ const testDataSpecVersion3 = `
 			"specialPurposes": [1, 2],
 			"features": [1, 2],
 			"specialFeatures": [1, 2],
+			"usesCookies": false,
+			"cookieMaxAgeSeconds": null,
+			"usesNonCookieAccess": true,
 			"dataRetention": {
 				"stdRetention": 30,
 				"purposes": { "9": 180 },
@@ -153,6 +374,12 @@ const testDataSpecVersion3 = `
 			"specialPurposes": [],
 			"features": [],
 			"specialFeatures": [],
+			"deletedDate": "2020-06-28T00:00:00Z",
+			"usesCookies": true,
+			"cookieMaxAgeSeconds": 2592000,
+			"cookieRefresh": true,
+			"usesNonCookieAccess": false,
+			"deviceStorageDisclosureUrl": "https://platform-cdn.sharethrough.com/device-storage.json",
 			"dataRetention": {
 				"stdRetention": 30,
 				"purposes": { "9": 180 },
@@ -171,6 +398,44 @@ const testDataSpecVersion3 = `
 				"legIntClaim": "https://vendorname.com/fr/gdpr.html#li"
 			  }
 		   ]
+		},
+		"81": {
+			"id": 81,
+			"name": "Partial Data Declaration Ltd",
+			"purposes": [1, 2],
+			"legIntPurposes": [],
+			"flexiblePurposes": [],
+			"specialPurposes": [1],
+			"features": [],
+			"specialFeatures": [],
+			"dataRetention": {
+				"purposes": { "2": 365 },
+				"specialPurposes": { "1": 90 }
+			},
+			"dataDeclaration": [ 3 ],
+			"urls": [
+				{
+					"langId": "en",
+					"privacy": "https://partial.example.com/privacy-policy"
+				}
+			]
+		}
+	},
+	"dataCategories": {
+		"1": {
+			"id": 1,
+			"name": "IP addresses",
+			"description": "Your IP address is a number assigned by your Internet Service Provider to any Internet connection."
+		},
+		"3": {
+			"id": 3,
+			"name": "Device identifiers",
+			"description": "A device identifier is a unique string of characters assigned to your device or browser by means of a cookie or other storage technologies."
+		},
+		"2": {
+			"id": 2,
+			"name": "Device characteristics",
+			"description": "Technical characteristics about the device you are using."
 		}
 	}
 }
@@ -193,6 +458,13 @@ func assertIntsEqual(t *testing.T, expected int, actual int) {
 	}
 }
 
+func assertStringsEqual(t *testing.T, expected string, actual string) {
+	t.Helper()
+	if actual != expected {
+		t.Errorf("Strings were not equal. Expected %s, actual %s", expected, actual)
+	}
+}
+
 func assertBoolsEqual(t *testing.T, expected bool, actual bool) {
 	t.Helper()
 	if actual != expected {
@@ -209,3 +481,18 @@ func assertNil(t *testing.T, value api.Vendor, expectNil bool) {
 		t.Errorf("The vendor should not be nil, but was.")
 	}
 }
+
+// testDataMalformedMetadata holds vendors with deletion dates and cookie durations which can't be decoded,
+// next to vendor 82 whose values are valid
+const testDataMalformedMetadata = `
+{
+	"gvlSpecificationVersion": 2,
+	"vendorListVersion": 28,
+	"vendors": {
+		"8": {"id": 8, "name": "Emerse Sverige AB", "purposes": [1], "deletedDate": "2020-06-28", "usesCookies": true, "cookieMaxAgeSeconds": 86400.5},
+		"80": {"id": 80, "name": "Sharethrough, Inc", "purposes": [1, 2], "deletedDate": 20200628, "usesCookies": true, "cookieMaxAgeSeconds": "86400"},
+		"81": {"id": 81, "name": "Partial Data Declaration Ltd", "purposes": [1], "deletedDate": null, "usesCookies": true, "cookieMaxAgeSeconds": null},
+		"82": {"id": 82, "name": "Valid Metadata Ltd", "purposes": [1], "deletedDate": "2020-06-28T00:00:00Z", "usesCookies": true, "cookieMaxAgeSeconds": 86400}
+	}
+}
+`
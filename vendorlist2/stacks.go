@@ -0,0 +1,181 @@
+package vendorlist2
+
+import (
+	"math/bits"
+
+	"github.com/prebid/go-gdpr/api"
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+// ResolveStack returns the purposes and special features of the stack.
+// The last return value is false if the vendor list doesn't define the stack, or doesn't implement api.VendorListStacks.
+func ResolveStack(vendorList api.VendorList, stackID uint8) ([]consentconstants.Purpose, []consentconstants.SpecialFeature, bool) {
+	stacks, ok := vendorList.(api.VendorListStacks)
+	if !ok {
+		return nil, nil, false
+	}
+	stack, ok := stacks.Stack(stackID)
+	if !ok {
+		return nil, nil, false
+	}
+	return stack.Purposes, stack.SpecialFeatures, true
+}
+
+// StackCover is a way to present purposes and special features with stacks.
+type StackCover struct {
+	// Stacks are the stacks to present, sorted by ID. They don't share any purpose or special feature.
+	Stacks []api.Stack
+	// Purposes are the purposes which none of the Stacks covers, and must be presented on their own.
+	Purposes []consentconstants.Purpose
+	// SpecialFeatures are the special features which none of the Stacks covers, and must be presented on their own.
+	SpecialFeatures []consentconstants.SpecialFeature
+}
+
+// CoverWithStacks picks the stacks of the vendor list which present the purposes and special features.
+// A stack can only be picked if every one of its purposes and special features was requested, and the picked
+// stacks don't share any of them.
+//
+// The stacks cover as many of the purposes and special features as possible, with as few stacks as possible.
+// Among equally good covers, the one with the lowest stack IDs wins. If the vendor list doesn't implement
+// api.VendorListStacks, nothing is covered.
+func CoverWithStacks(vendorList api.VendorList, purposes []consentconstants.Purpose, specialFeatures []consentconstants.SpecialFeature) StackCover {
+	var requested uint64
+	for _, purpose := range purposes {
+		requested |= purposeBit(purpose)
+	}
+	for _, feature := range specialFeatures {
+		requested |= specialFeatureBit(feature)
+	}
+
+	search := stackSearch{}
+	if stacks, ok := vendorList.(api.VendorListStacks); ok {
+		for _, stack := range stacks.Stacks() {
+			if mask, ok := stackMask(stack); ok && mask&^requested == 0 {
+				search.candidates = append(search.candidates, stackCandidate{stack: stack, mask: mask})
+			}
+		}
+	}
+	search.run()
+
+	cover := StackCover{}
+	var covered uint64
+	for _, i := range search.best {
+		cover.Stacks = append(cover.Stacks, search.candidates[i].stack)
+		covered |= search.candidates[i].mask
+	}
+	for _, purpose := range purposes {
+		if purposeBit(purpose)&covered == 0 {
+			cover.Purposes = append(cover.Purposes, purpose)
+		}
+	}
+	for _, feature := range specialFeatures {
+		if specialFeatureBit(feature)&covered == 0 {
+			cover.SpecialFeatures = append(cover.SpecialFeatures, feature)
+		}
+	}
+	return cover
+}
+
+// VendorStacks runs CoverWithStacks on the purposes, whatever their legal basis, and the special features which
+// the vendor declared. The second return value is false if the vendor isn't in the vendor list.
+func VendorStacks(vendorList api.VendorList, vendorID uint16) (StackCover, bool) {
+	vendor := vendorList.Vendor(vendorID)
+	if vendor == nil {
+		return StackCover{}, false
+	}
+	var purposes []consentconstants.Purpose
+	for purpose := consentconstants.Purpose(1); purpose <= 24; purpose++ {
+		if vendor.Purpose(purpose) || vendor.LegitimateInterest(purpose) {
+			purposes = append(purposes, purpose)
+		}
+	}
+	var specialFeatures []consentconstants.SpecialFeature
+	for feature := consentconstants.SpecialFeature(1); feature <= 12; feature++ {
+		if vendor.SpecialFeature(feature) {
+			specialFeatures = append(specialFeatures, feature)
+		}
+	}
+	return CoverWithStacks(vendorList, purposes, specialFeatures), true
+}
+
+// Purposes 1 to 24 use bits 0 to 23 of the masks, and special features 1 to 12 use bits 24 to 35.
+func purposeBit(purpose consentconstants.Purpose) uint64 {
+	if purpose < 1 || purpose > 24 {
+		return 0
+	}
+	return 1 << (purpose - 1)
+}
+
+func specialFeatureBit(feature consentconstants.SpecialFeature) uint64 {
+	if feature < 1 || feature > 12 {
+		return 0
+	}
+	return 1 << (24 + feature - 1)
+}
+
+// stackMask returns the bits of the stack's purposes and special features. It returns false for stacks which
+// are empty, or hold IDs which don't fit in the mask.
+func stackMask(stack api.Stack) (uint64, bool) {
+	var mask uint64
+	for _, purpose := range stack.Purposes {
+		bit := purposeBit(purpose)
+		if bit == 0 {
+			return 0, false
+		}
+		mask |= bit
+	}
+	for _, feature := range stack.SpecialFeatures {
+		bit := specialFeatureBit(feature)
+		if bit == 0 {
+			return 0, false
+		}
+		mask |= bit
+	}
+	return mask, mask != 0
+}
+
+type stackCandidate struct {
+	stack api.Stack
+	mask  uint64
+}
+
+// stackSearch finds the best set of disjoint candidates by backtracking. Vendor lists have a few dozen stacks
+// at most, and most of them overlap, so the search stays small.
+type stackSearch struct {
+	candidates []stackCandidate
+	// reachable[i] is the union of the masks of candidates[i:]
+	reachable []uint64
+
+	chosen      []int
+	best        []int
+	bestCovered int
+}
+
+func (s *stackSearch) run() {
+	s.reachable = make([]uint64, len(s.candidates)+1)
+	for i := len(s.candidates) - 1; i >= 0; i-- {
+		s.reachable[i] = s.reachable[i+1] | s.candidates[i].mask
+	}
+	s.visit(0, 0)
+}
+
+func (s *stackSearch) visit(next int, covered uint64) {
+	coveredCount := bits.OnesCount64(covered)
+	if coveredCount > s.bestCovered || (coveredCount == s.bestCovered && len(s.chosen) < len(s.best)) {
+		s.best = append(s.best[:0], s.chosen...)
+		s.bestCovered = coveredCount
+	}
+	for i := next; i < len(s.candidates); i++ {
+		// Even the stacks which overlap can't cover more than this, so give up if it can't beat the best
+		upperBound := bits.OnesCount64(covered | s.reachable[i])
+		if upperBound < s.bestCovered || (upperBound == s.bestCovered && len(s.chosen)+1 >= len(s.best)) {
+			return
+		}
+		if s.candidates[i].mask&covered != 0 {
+			continue
+		}
+		s.chosen = append(s.chosen, i)
+		s.visit(i+1, covered|s.candidates[i].mask)
+		s.chosen = s.chosen[:len(s.chosen)-1]
+	}
+}
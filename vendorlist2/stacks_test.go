@@ -0,0 +1,157 @@
+package vendorlist2
+
+import (
+	"testing"
+
+	"github.com/prebid/go-gdpr/api"
+	"github.com/prebid/go-gdpr/consentconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDataStacks declares stacks 5 and 9 with the same purposes, so that the lowest ID wins
+const testDataStacks = `
+{
+	"gvlSpecificationVersion": 3,
+	"vendorListVersion": 1,
+	"tcfPolicyVersion": 4,
+	"stacks": {
+		"1": {"id": 1, "name": "Precise geolocation data, and identification through device scanning", "description": "", "purposes": [], "specialFeatures": [1, 2]},
+		"2": {"id": 2, "name": "Advertising based on limited data and advertising measurement", "description": "", "purposes": [2, 7], "specialFeatures": []},
+		"3": {"id": 3, "name": "Personalised advertising", "description": "", "purposes": [2, 3, 4], "specialFeatures": []},
+		"4": {"id": 4, "name": "Advertising and audience research", "description": "", "purposes": [2, 7, 9], "specialFeatures": []},
+		"5": {"id": 5, "name": "Personalised advertising profile and display", "description": "", "purposes": [3, 4], "specialFeatures": []},
+		"6": {"id": 6, "name": "Measurement and development", "description": "", "purposes": [7, 9, 10], "specialFeatures": []},
+		"7": {"id": 7, "name": "Personalised advertising, measurement and development", "description": "Ads can be personalised.", "purposes": [2, 3, 4, 7, 9, 10], "specialFeatures": []},
+		"9": {"id": 9, "name": "Personalised advertising profile and display", "description": "", "purposes": [3, 4], "specialFeatures": []}
+	},
+	"vendors": {
+		"1": {"id": 1, "purposes": [1, 2, 3, 4], "legIntPurposes": [7, 9, 10], "flexiblePurposes": [], "specialPurposes": [], "specialFeatures": [1, 2]},
+		"2": {"id": 2, "purposes": [2, 3, 4, 7], "legIntPurposes": [], "flexiblePurposes": [], "specialPurposes": [], "specialFeatures": []},
+		"3": {"id": 3, "purposes": [2], "legIntPurposes": [7, 9], "flexiblePurposes": [2], "specialPurposes": [], "specialFeatures": []},
+		"4": {"id": 4, "purposes": [1, 3], "legIntPurposes": [], "flexiblePurposes": [], "specialPurposes": [], "specialFeatures": [1]}
+	}
+}
+`
+
+func TestResolveStack(t *testing.T) {
+	for name, vendorList := range parseStacksTestData(t) {
+		t.Run(name, func(t *testing.T) {
+			purposes, specialFeatures, ok := ResolveStack(vendorList, 3)
+			assert.True(t, ok)
+			assert.Equal(t, []consentconstants.Purpose{2, 3, 4}, purposes)
+			assert.Empty(t, specialFeatures)
+
+			purposes, specialFeatures, ok = ResolveStack(vendorList, 1)
+			assert.True(t, ok)
+			assert.Empty(t, purposes)
+			assert.Equal(t, []consentconstants.SpecialFeature{1, 2}, specialFeatures)
+
+			_, _, ok = ResolveStack(vendorList, 8)
+			assert.False(t, ok)
+
+			stack, ok := vendorList.(api.VendorListStacks).Stack(7)
+			assert.True(t, ok)
+			assert.Equal(t, uint8(7), stack.ID)
+			assert.Equal(t, "Personalised advertising, measurement and development", stack.Name)
+			assert.Equal(t, "Ads can be personalised.", stack.Description)
+			assert.Equal(t, []consentconstants.Purpose{2, 3, 4, 7, 9, 10}, stack.Purposes)
+			assert.Empty(t, stack.SpecialFeatures)
+
+			stacks := vendorList.(api.VendorListStacks).Stacks()
+			if assert.Len(t, stacks, 8) {
+				assert.Equal(t, uint8(1), stacks[0].ID)
+				assert.Equal(t, uint8(9), stacks[7].ID)
+			}
+		})
+	}
+}
+
+func TestVendorStacks(t *testing.T) {
+	tests := []struct {
+		name     string
+		vendorID uint16
+		expected stackCoverWithIDs
+	}{
+		{
+			name:     "one_big_stack_rather_than_two",
+			vendorID: 1,
+			expected: stackCoverWithIDs{Stacks: []uint8{1, 7}, Purposes: []consentconstants.Purpose{1}},
+		},
+		{
+			name:     "more_purposes_rather_than_fewer_stacks",
+			vendorID: 2,
+			expected: stackCoverWithIDs{Stacks: []uint8{2, 5}},
+		},
+		{
+			name:     "flexible_and_legitimate_interest_purposes",
+			vendorID: 3,
+			expected: stackCoverWithIDs{Stacks: []uint8{4}},
+		},
+		{
+			name:     "no_stack",
+			vendorID: 4,
+			expected: stackCoverWithIDs{
+				Purposes:        []consentconstants.Purpose{1, 3},
+				SpecialFeatures: []consentconstants.SpecialFeature{1},
+			},
+		},
+	}
+
+	for name, vendorList := range parseStacksTestData(t) {
+		for _, tt := range tests {
+			t.Run(name+"_"+tt.name, func(t *testing.T) {
+				cover, ok := VendorStacks(vendorList, tt.vendorID)
+				assert.True(t, ok)
+				assert.Equal(t, tt.expected, stackCoverIDs(cover))
+			})
+		}
+		_, ok := VendorStacks(vendorList, 5)
+		assert.False(t, ok)
+	}
+}
+
+func TestCoverWithStacks(t *testing.T) {
+	vendorList, err := ParseEagerly([]byte(testDataStacks))
+	assert.NoError(t, err)
+
+	cover := CoverWithStacks(vendorList, []consentconstants.Purpose{3, 4, 11}, []consentconstants.SpecialFeature{2})
+	assert.Equal(t, stackCoverWithIDs{
+		Stacks:          []uint8{5},
+		Purposes:        []consentconstants.Purpose{11},
+		SpecialFeatures: []consentconstants.SpecialFeature{2},
+	}, stackCoverIDs(cover))
+
+	assert.Equal(t, stackCoverWithIDs{}, stackCoverIDs(CoverWithStacks(vendorList, nil, nil)))
+
+	// Vendor lists which don't implement api.VendorListStacks have no stack to offer
+	withoutStacks := struct{ api.VendorList }{vendorList}
+	cover = CoverWithStacks(withoutStacks, []consentconstants.Purpose{3, 4}, nil)
+	assert.Equal(t, stackCoverWithIDs{Purposes: []consentconstants.Purpose{3, 4}}, stackCoverIDs(cover))
+	_, _, ok := ResolveStack(withoutStacks, 3)
+	assert.False(t, ok)
+}
+
+func parseStacksTestData(t *testing.T) map[string]api.VendorList {
+	t.Helper()
+	eager, err := ParseEagerly([]byte(testDataStacks))
+	assert.NoError(t, err)
+	return map[string]api.VendorList{
+		"eager": eager,
+		"lazy":  ParseLazily([]byte(testDataStacks)),
+	}
+}
+
+// stackCoverWithIDs is a StackCover with the IDs of its stacks, which keeps the expectations short
+type stackCoverWithIDs struct {
+	Stacks          []uint8
+	Purposes        []consentconstants.Purpose
+	SpecialFeatures []consentconstants.SpecialFeature
+}
+
+func stackCoverIDs(cover StackCover) stackCoverWithIDs {
+	withIDs := stackCoverWithIDs{Purposes: cover.Purposes, SpecialFeatures: cover.SpecialFeatures}
+	for _, stack := range cover.Stacks {
+		withIDs.Stacks = append(withIDs.Stacks, stack.ID)
+	}
+	return withIDs
+}
@@ -0,0 +1,175 @@
+package vendorlist2
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/prebid/go-gdpr/api"
+	"github.com/prebid/go-gdpr/consentconstants"
+)
+
+// LocalizedVendorList is a vendor list with translations of its purposes, special purposes, features,
+// special features and stacks. Its methods can be called safely from several goroutines, even while
+// translations are being added.
+//
+// Lookups take a language code, such as the ConsentLanguage of a consent string. They only find the IDs which
+// the vendor list defines, whatever the translations hold. They fall back to the primary language of codes like
+// "PT-BR", and then to the English of the vendor list itself, when there is no translation for the language or the ID.
+type LocalizedVendorList struct {
+	api.VendorList
+
+	mutex        sync.RWMutex
+	translations map[string]translation
+}
+
+// translation holds the content of a translation file
+type translation struct {
+	purposes        definitions
+	specialPurposes definitions
+	features        definitions
+	specialFeatures definitions
+	stacks          map[uint8]api.Stack
+}
+
+// NewLocalizedVendorList wraps a vendor list, such as the ones returned by ParseEagerly and ParseLazily.
+// Lookups only find the IDs which the vendor list defines, so it must implement api.VendorListDefinitions
+// and api.VendorListStacks.
+func NewLocalizedVendorList(vendorList api.VendorList) *LocalizedVendorList {
+	return &LocalizedVendorList{
+		VendorList:   vendorList,
+		translations: make(map[string]translation),
+	}
+}
+
+// AddTranslation parses a translation file published by the IAB, such as purposes-de.json, and merges it
+// as the given language. Adding a language twice replaces the previous translation.
+func (l *LocalizedVendorList) AddTranslation(language string, data []byte) error {
+	language = normalizeLanguage(language)
+	if language == "" {
+		return errors.New("the language of a translation can't be empty")
+	}
+	var contract translationContract
+	if err := json.Unmarshal(data, &contract); err != nil {
+		return err
+	}
+
+	parsed := translation{
+		purposes:        mapifyDefinitions(contract.Purposes),
+		specialPurposes: mapifyDefinitions(contract.SpecialPurposes),
+		features:        mapifyDefinitions(contract.Features),
+		specialFeatures: mapifyDefinitions(contract.SpecialFeatures),
+		stacks:          make(map[uint8]api.Stack, len(contract.Stacks)),
+	}
+	for _, stack := range contract.Stacks {
+		parsed.stacks[stack.ID] = stack
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.translations[language] = parsed
+	return nil
+}
+
+// Purpose returns the definition of the purpose in the language.
+func (l *LocalizedVendorList) Purpose(language string, id consentconstants.Purpose) (api.Definition, bool) {
+	return l.definition(language, func(t translation) definitions { return t.purposes }, uint8(id), func(d api.VendorListDefinitions) (api.Definition, bool) {
+		return d.Purpose(id)
+	})
+}
+
+// SpecialPurpose returns the definition of the special purpose in the language.
+func (l *LocalizedVendorList) SpecialPurpose(language string, id consentconstants.Purpose) (api.Definition, bool) {
+	return l.definition(language, func(t translation) definitions { return t.specialPurposes }, uint8(id), func(d api.VendorListDefinitions) (api.Definition, bool) {
+		return d.SpecialPurpose(id)
+	})
+}
+
+// Feature returns the definition of the feature in the language.
+func (l *LocalizedVendorList) Feature(language string, id uint8) (api.Definition, bool) {
+	return l.definition(language, func(t translation) definitions { return t.features }, id, func(d api.VendorListDefinitions) (api.Definition, bool) {
+		return d.Feature(id)
+	})
+}
+
+// SpecialFeature returns the definition of the special feature in the language.
+func (l *LocalizedVendorList) SpecialFeature(language string, id consentconstants.SpecialFeature) (api.Definition, bool) {
+	return l.definition(language, func(t translation) definitions { return t.specialFeatures }, uint8(id), func(d api.VendorListDefinitions) (api.Definition, bool) {
+		return d.SpecialFeature(id)
+	})
+}
+
+// Stack returns the stack with its name and description in the language. The purposes and special features
+// of the stack always come from the vendor list, so the stack must exist there.
+func (l *LocalizedVendorList) Stack(language string, id uint8) (api.Stack, bool) {
+	stacks, ok := l.VendorList.(api.VendorListStacks)
+	if !ok {
+		return api.Stack{}, false
+	}
+	stack, ok := stacks.Stack(id)
+	if !ok {
+		return api.Stack{}, false
+	}
+
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	for _, candidate := range languageCandidates(language) {
+		if translated, ok := l.translations[candidate].stacks[id]; ok {
+			stack.Name = translated.Name
+			stack.Description = translated.Description
+			break
+		}
+	}
+	return stack, true
+}
+
+// definition looks the ID up in the vendor list, and then replaces it with its translation in the language if there is one
+func (l *LocalizedVendorList) definition(language string, translated func(translation) definitions, id uint8, english func(api.VendorListDefinitions) (api.Definition, bool)) (api.Definition, bool) {
+	definitions, ok := l.VendorList.(api.VendorListDefinitions)
+	if !ok {
+		return api.Definition{}, false
+	}
+	definition, ok := english(definitions)
+	if !ok {
+		return api.Definition{}, false
+	}
+
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	for _, candidate := range languageCandidates(language) {
+		if t, ok := l.translations[candidate]; ok {
+			if translatedDefinition, ok := translated(t).get(id); ok {
+				return translatedDefinition, true
+			}
+		}
+	}
+	return definition, true
+}
+
+// languageCandidates returns the languages to try for a language code, from the most to the least specific.
+// The vendor list itself is in English, so English is left out.
+func languageCandidates(language string) []string {
+	language = normalizeLanguage(language)
+	if language == "" {
+		return nil
+	}
+	candidates := []string{language}
+	if i := strings.IndexByte(language, '-'); i > 0 {
+		candidates = append(candidates, language[:i])
+	}
+	return candidates
+}
+
+// normalizeLanguage turns codes like "DE" from consent strings into "de" like the names of the translation files
+func normalizeLanguage(language string) string {
+	return strings.ToLower(strings.TrimSpace(language))
+}
+
+type translationContract struct {
+	Purposes        map[string]api.Definition `json:"purposes"`
+	SpecialPurposes map[string]api.Definition `json:"specialPurposes"`
+	Features        map[string]api.Definition `json:"features"`
+	SpecialFeatures map[string]api.Definition `json:"specialFeatures"`
+	Stacks          map[string]api.Stack      `json:"stacks"`
+}
@@ -0,0 +1,181 @@
+package vendorlist2
+
+import (
+	"testing"
+
+	"github.com/prebid/go-gdpr/api"
+	"github.com/prebid/go-gdpr/consentconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDataTranslationDE is shaped like purposes-de.json, which only translates some of the definitions here
+const testDataTranslationDE = `
+{
+	"gvlSpecificationVersion": 3,
+	"language": "de",
+	"purposes": {
+		"1": {
+			"id": 1,
+			"name": "Speichern von oder Zugriff auf Informationen auf einem Endgerät",
+			"description": "Cookies, Endgeräte- oder ähnliche Online-Kennungen können auf Ihrem Endgerät gespeichert oder von dort abgerufen werden.",
+			"illustrations": []
+		}
+	},
+	"specialPurposes": {
+		"1": {
+			"id": 1,
+			"name": "Gewährleistung der Sicherheit, Verhinderung und Aufdeckung von Betrug und Fehlerbehebung",
+			"description": "Ihre Daten können verwendet werden, um ungewöhnliche und potenziell betrügerische Aktivitäten zu überwachen.",
+			"illustrations": []
+		}
+	},
+	"features": {},
+	"specialFeatures": {
+		"1": {
+			"id": 1,
+			"name": "Genaue Standortdaten verwenden",
+			"description": "Mit Ihrer Zustimmung kann Ihr genauer Standort verwendet werden.",
+			"illustrations": []
+		},
+		"2": {
+			"id": 2,
+			"name": "Endgeräteeigenschaften zur Identifikation aktiv abfragen",
+			"description": "Mit Ihrer Zustimmung können bestimmte Merkmale Ihres Geräts abgefragt werden.",
+			"illustrations": []
+		}
+	},
+	"stacks": {
+		"3": {
+			"id": 3,
+			"name": "Personalisierte Werbung",
+			"description": "Werbung kann personalisiert werden.",
+			"purposes": [2, 3, 4],
+			"specialFeatures": []
+		}
+	}
+}
+`
+
+const testDataTranslationPT = `
+{
+	"purposes": {
+		"1": {"id": 1, "name": "Armazenar e/ou aceder a informações num dispositivo", "description": "", "illustrations": []}
+	}
+}
+`
+
+func TestLocalizedDefinitions(t *testing.T) {
+	for name, vendorList := range parseTranslationsTestData(t, testDataSpecVersion3) {
+		t.Run(name, func(t *testing.T) {
+			localized := NewLocalizedVendorList(vendorList)
+			assert.NoError(t, localized.AddTranslation("de", []byte(testDataTranslationDE)))
+			assert.NoError(t, localized.AddTranslation("pt", []byte(testDataTranslationPT)))
+
+			// Consent strings hold upper case languages, while the translation files use lower case
+			purpose, ok := localized.Purpose("DE", 1)
+			assert.True(t, ok)
+			assert.Equal(t, uint8(1), purpose.ID)
+			assert.Equal(t, "Speichern von oder Zugriff auf Informationen auf einem Endgerät", purpose.Name)
+
+			specialPurpose, ok := localized.SpecialPurpose("DE", 1)
+			assert.True(t, ok)
+			assert.Equal(t, "Gewährleistung der Sicherheit, Verhinderung und Aufdeckung von Betrug und Fehlerbehebung", specialPurpose.Name)
+
+			specialFeature, ok := localized.SpecialFeature("DE", consentconstants.SpecialFeature(1))
+			assert.True(t, ok)
+			assert.Equal(t, "Genaue Standortdaten verwenden", specialFeature.Name)
+
+			// Definitions which the translation leaves out are in English
+			purpose, ok = localized.Purpose("DE", 2)
+			assert.True(t, ok)
+			assert.Equal(t, "Use limited data to select advertising", purpose.Name)
+			assert.Len(t, purpose.Illustrations, 2)
+
+			feature, ok := localized.Feature("DE", 1)
+			assert.True(t, ok)
+			assert.Equal(t, "Match and combine data from other data sources", feature.Name)
+
+			// Regional languages fall back to their primary language
+			purpose, ok = localized.Purpose("PT-BR", 1)
+			assert.True(t, ok)
+			assert.Equal(t, "Armazenar e/ou aceder a informações num dispositivo", purpose.Name)
+
+			// Languages without a translation are in English
+			for _, language := range []string{"FR", "EN", ""} {
+				purpose, ok = localized.Purpose(language, 1)
+				assert.True(t, ok)
+				assert.Equal(t, "Store and/or access information on a device", purpose.Name)
+			}
+
+			_, ok = localized.Purpose("DE", 3)
+			assert.False(t, ok)
+
+			// The translation defines special feature 2 and stack 3, which this vendor list doesn't
+			_, ok = localized.SpecialFeature("DE", 2)
+			assert.False(t, ok)
+			_, ok = localized.Stack("DE", 3)
+			assert.False(t, ok)
+
+			// The vendors are still those of the vendor list
+			assert.NotNil(t, localized.Vendor(8))
+			assert.Equal(t, uint16(3), localized.SpecVersion())
+		})
+	}
+}
+
+func TestLocalizedStacks(t *testing.T) {
+	for name, vendorList := range parseTranslationsTestData(t, testDataStacks) {
+		t.Run(name, func(t *testing.T) {
+			localized := NewLocalizedVendorList(vendorList)
+			assert.NoError(t, localized.AddTranslation("DE", []byte(testDataTranslationDE)))
+
+			stack, ok := localized.Stack("de", 3)
+			assert.True(t, ok)
+			assert.Equal(t, uint8(3), stack.ID)
+			assert.Equal(t, "Personalisierte Werbung", stack.Name)
+			assert.Equal(t, "Werbung kann personalisiert werden.", stack.Description)
+			assert.Equal(t, []consentconstants.Purpose{2, 3, 4}, stack.Purposes)
+
+			stack, ok = localized.Stack("DE", 7)
+			assert.True(t, ok)
+			assert.Equal(t, "Personalised advertising, measurement and development", stack.Name)
+
+			_, ok = localized.Stack("DE", 8)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestAddTranslationErrors(t *testing.T) {
+	vendorList, err := ParseEagerly([]byte(testDataSpecVersion3))
+	assert.NoError(t, err)
+	localized := NewLocalizedVendorList(vendorList)
+
+	assert.Error(t, localized.AddTranslation("de", []byte(`{"purposes": [`)))
+	assert.Error(t, localized.AddTranslation(" ", []byte(testDataTranslationDE)))
+
+	// Nothing was added by the failed attempts
+	purpose, ok := localized.Purpose("DE", 1)
+	assert.True(t, ok)
+	assert.Equal(t, "Store and/or access information on a device", purpose.Name)
+
+	// Vendor lists which don't implement api.VendorListDefinitions define nothing, even with translations
+	withoutDefinitions := NewLocalizedVendorList(struct{ api.VendorList }{vendorList})
+	assert.NoError(t, withoutDefinitions.AddTranslation("de", []byte(testDataTranslationDE)))
+	_, ok = withoutDefinitions.Purpose("FR", 1)
+	assert.False(t, ok)
+	_, ok = withoutDefinitions.Purpose("DE", 1)
+	assert.False(t, ok)
+	_, ok = withoutDefinitions.Stack("DE", 3)
+	assert.False(t, ok)
+}
+
+func parseTranslationsTestData(t *testing.T, data string) map[string]api.VendorList {
+	t.Helper()
+	eager, err := ParseEagerly([]byte(data))
+	assert.NoError(t, err)
+	return map[string]api.VendorList{
+		"eager": eager,
+		"lazy":  ParseLazily([]byte(data)),
+	}
+}